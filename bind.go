@@ -0,0 +1,457 @@
+package laks
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// HostObject is a Go struct or map bound into scripts, with
+// Interpreter.SetGlobal or by being returned from Go. Scripts read and set
+// a struct's exported fields and a map's keys as attributes, and call a
+// struct's exported methods.
+//
+// Scripts can only write lower case names, so Go names are used in
+// snake_case: a field UserID is user_id. A `laks:"name"` tag on a field
+// gives it another name.
+type HostObject struct {
+	v reflect.Value // an addressable struct, or a map
+
+	// in_map is set for a copy of a struct stored in a map. Its fields
+	// cannot be set, since the map would not see the change.
+	in_map bool
+}
+
+// SetGlobal binds v to name for scripts to use. Ints, uints, floats,
+// strings and bools become their laks equivalents, slices and arrays become
+// lists, and structs and maps with string keys become objects. Pointers to
+// structs are shared, along with the structs in their fields, so a script
+// setting a field changes the Go value; other values are copied. A struct
+// stored in a map is read as a copy whose fields cannot be set; a script
+// sets the map's key to a new value instead. Any type laks cannot use,
+// including in a field or method of a struct, is an error here rather than
+// when a script uses it.
+func (in *Interpreter) SetGlobal(name string, v any) error {
+	if v != nil {
+		err := check_bindable(reflect.TypeOf(v), map[reflect.Type]bool{})
+		if err != nil {
+			return fmt.Errorf("cannot bind '%s'. %w", name, err)
+		}
+	}
+	value, err := ToValue(v)
+	if err != nil {
+		return fmt.Errorf("cannot bind '%s'. %w", name, err)
+	}
+	in.bi.globals[name] = value
+	return nil
+}
+
+var error_type = reflect.TypeFor[error]()
+
+// check_bindable reports whether every value of type t can be converted
+// to and from laks values. seen stops recursive types going round forever.
+func check_bindable(t reflect.Type, seen map[reflect.Type]bool) error {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Interface:
+		// Only any, whose values are checked when they are converted.
+		if t.NumMethod() == 0 {
+			return nil
+		}
+	case reflect.Slice, reflect.Array:
+		return check_bindable(t.Elem(), seen)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("map keys must be strings but %v has %v keys", t, t.Key())
+		}
+		return check_bindable(t.Elem(), seen)
+	case reflect.Pointer:
+		if t.Elem().Kind() == reflect.Struct {
+			return check_struct(t.Elem(), seen)
+		}
+	case reflect.Struct:
+		return check_struct(t, seen)
+	}
+	return fmt.Errorf("laks cannot use values of type %v", t)
+}
+
+func check_struct(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		if err := check_bindable(f.Type, seen); err != nil {
+			return fmt.Errorf("field %v.%s. %w", t, f.Name, err)
+		}
+	}
+	pt := reflect.PointerTo(t)
+	for i := range pt.NumMethod() {
+		m := pt.Method(i)
+		if err := check_method(m.Type, seen); err != nil {
+			return fmt.Errorf("method %v.%s. %w", t, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// check_method checks a method can be called from scripts. Its results
+// can be nothing, a value, an error, or a value and an error. Types already
+// in seen are being checked further up, so a method can mention its own
+// type.
+func check_method(t reflect.Type, seen map[reflect.Type]bool) error {
+	for i := 1; i < t.NumIn(); i++ {
+		if err := check_bindable(t.In(i), seen); err != nil {
+			return err
+		}
+	}
+	switch {
+	case t.NumOut() == 0:
+	case t.NumOut() == 1 && t.Out(0) == error_type:
+	case t.NumOut() == 1:
+		return check_bindable(t.Out(0), seen)
+	case t.NumOut() == 2 && t.Out(1) == error_type:
+		return check_bindable(t.Out(0), seen)
+	default:
+		return fmt.Errorf("returns %d values. only a value, an error, or both can be returned", t.NumOut())
+	}
+	return nil
+}
+
+// type_name is the name of the object's Go type, for messages.
+func (o *HostObject) type_name() string {
+	if name := o.v.Type().Name(); name != "" {
+		return name
+	}
+	return o.v.Type().String()
+}
+
+// get reads the attribute called name: a field, then a method, or a key of
+// a map.
+func (o *HostObject) get(name string) (Value, error) {
+	if o.v.Kind() == reflect.Map {
+		v := o.v.MapIndex(reflect.ValueOf(name).Convert(o.v.Type().Key()))
+		if !v.IsValid() {
+			return nil, with_hint(fmt.Errorf("%s has no key '%s'", o.type_name(), name), did_you_mean(name, o.names()))
+		}
+		if v.Kind() == reflect.Struct {
+			c := reflect.New(v.Type()).Elem()
+			c.Set(v)
+			return &HostObject{v: c, in_map: true}, nil
+		}
+		return ToValue(v.Interface())
+	}
+
+	if f, ok := o.field(name); ok {
+		if f.Kind() == reflect.Struct {
+			// The field itself rather than a copy, so setting its fields
+			// changes this object.
+			return &HostObject{v: f, in_map: o.in_map}, nil
+		}
+		return ToValue(f.Interface())
+	}
+	if m, ok := o.method(name); ok {
+		return m, nil
+	}
+	return nil, with_hint(fmt.Errorf("%s has no attribute '%s'", o.type_name(), name), did_you_mean(name, o.names()))
+}
+
+// set sets the field or map key called name to v, converted to its Go type.
+func (o *HostObject) set(name string, v Value) error {
+	if o.v.Kind() == reflect.Map {
+		if o.v.IsNil() {
+			return fmt.Errorf("cannot set '%s' of a nil %s", name, o.type_name())
+		}
+		gv, err := value_to(v, o.v.Type().Elem())
+		if err != nil {
+			return fmt.Errorf("cannot set '%s'. %w", name, err)
+		}
+		o.v.SetMapIndex(reflect.ValueOf(name).Convert(o.v.Type().Key()), gv)
+		return nil
+	}
+
+	f, ok := o.field(name)
+	if !ok {
+		return with_hint(fmt.Errorf("%s has no field '%s'", o.type_name(), name), did_you_mean(name, o.names()))
+	}
+	if o.in_map {
+		err := fmt.Errorf("cannot set '%s'. this %s is a copy of one stored in a map", name, o.type_name())
+		return with_hint(err, "set the map's key to a new value instead")
+	}
+	gv, err := value_to(v, f.Type())
+	if err != nil {
+		return fmt.Errorf("cannot set '%s'. %w", name, err)
+	}
+	f.Set(gv)
+	return nil
+}
+
+// field finds the exported field whose script name is name.
+func (o *HostObject) field(name string) (reflect.Value, bool) {
+	for _, f := range reflect.VisibleFields(o.v.Type()) {
+		if f.IsExported() && !f.Anonymous && field_name(f) == name {
+			// Fails if the field is in a nil embedded pointer.
+			v, err := o.v.FieldByIndexErr(f.Index)
+			return v, err == nil
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// method finds the exported method whose script name is name, bound to
+// the object.
+func (o *HostObject) method(name string) (*NativeFunction, bool) {
+	pv := o.v.Addr()
+	for i := range pv.NumMethod() {
+		if script_name(pv.Type().Method(i).Name) == name {
+			return bind_method(o.type_name()+"."+name, pv.Method(i)), true
+		}
+	}
+	return nil, false
+}
+
+// same reports whether two objects are the same Go value.
+func (o *HostObject) same(other *HostObject) bool {
+	if o.v.Type() != other.v.Type() {
+		return false
+	}
+	if o.v.Kind() == reflect.Map {
+		return o.v.Pointer() == other.v.Pointer()
+	}
+	return o.v.Addr().Pointer() == other.v.Addr().Pointer()
+}
+
+// names are every attribute the object has, for suggestions.
+func (o *HostObject) names() []string {
+	if o.v.Kind() == reflect.Map {
+		var names []string
+		for _, k := range o.v.MapKeys() {
+			names = append(names, k.String())
+		}
+		return names
+	}
+	var names []string
+	for _, f := range reflect.VisibleFields(o.v.Type()) {
+		if f.IsExported() && !f.Anonymous {
+			names = append(names, field_name(f))
+		}
+	}
+	pt := o.v.Addr().Type()
+	for i := range pt.NumMethod() {
+		names = append(names, script_name(pt.Method(i).Name))
+	}
+	return names
+}
+
+// bind_method makes a native that calls m, converting its arguments from
+// laks values and its result back.
+func bind_method(name string, m reflect.Value) *NativeFunction {
+	t := m.Type()
+	arity := t.NumIn()
+	if t.IsVariadic() {
+		arity = -1
+	}
+	return &NativeFunction{Name: name, Arity: arity, Fn: func(args []Value) (Value, error) {
+		if t.IsVariadic() && len(args) < t.NumIn()-1 {
+			return nil, fmt.Errorf("%s wants at least %d arguments but got %d", name, t.NumIn()-1, len(args))
+		}
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			at := arg_type(t, i)
+			v, err := value_to(arg, at)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d. %w", i+1, err)
+			}
+			in[i] = v
+		}
+
+		out := m.Call(in)
+		if n := len(out); n > 0 && t.Out(n-1) == error_type {
+			if err, _ := out[n-1].Interface().(error); err != nil {
+				return nil, err
+			}
+			out = out[:n-1]
+		}
+		if len(out) == 0 {
+			return NilValue{}, nil
+		}
+		return ToValue(out[0].Interface())
+	}}
+}
+
+// arg_type is the type of argument i of a function of type t, which for
+// the variadic part is the type of each element.
+func arg_type(t reflect.Type, i int) reflect.Type {
+	if t.IsVariadic() && i >= t.NumIn()-1 {
+		return t.In(t.NumIn() - 1).Elem()
+	}
+	return t.In(i)
+}
+
+// value_to converts a laks value to a Go value of type t.
+func value_to(v Value, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Interface {
+		if _, is_nil := v.(NilValue); is_nil || v == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(FromValue(v)), nil
+	}
+
+	mismatch := fmt.Errorf("wants %v but got %s", t, type_name(value_type(v)))
+	rv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		switch v.(type) {
+		case TrueValue:
+			rv.SetBool(true)
+		case FalseValue:
+			rv.SetBool(false)
+		default:
+			return rv, mismatch
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := v.(IntValue)
+		if !ok {
+			return rv, mismatch
+		}
+		if rv.OverflowInt(int64(i)) {
+			return rv, fmt.Errorf("%d does not fit in %v", i, t)
+		}
+		rv.SetInt(int64(i))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := v.(IntValue)
+		if !ok {
+			return rv, mismatch
+		}
+		if i < 0 || rv.OverflowUint(uint64(i)) {
+			return rv, fmt.Errorf("%d does not fit in %v", i, t)
+		}
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		if !is_number(v) {
+			return rv, mismatch
+		}
+		rv.SetFloat(to_float(v))
+	case reflect.String:
+		s, ok := v.(StringValue)
+		if !ok {
+			return rv, mismatch
+		}
+		rv.SetString(string(s))
+	case reflect.Slice, reflect.Array:
+		if _, is_nil := v.(NilValue); is_nil && t.Kind() == reflect.Slice {
+			return rv, nil
+		}
+		list, ok := v.(ListValue)
+		if !ok {
+			return rv, mismatch
+		}
+		if t.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(t, len(list), len(list)))
+		} else if len(list) != t.Len() {
+			return rv, fmt.Errorf("wants %d values for %v but got %d", t.Len(), t, len(list))
+		}
+		for i, e := range list {
+			ev, err := value_to(e, t.Elem())
+			if err != nil {
+				return rv, fmt.Errorf("element %d. %w", i, err)
+			}
+			rv.Index(i).Set(ev)
+		}
+	case reflect.Map:
+		return map_to(v, t, mismatch)
+	case reflect.Pointer:
+		if _, is_nil := v.(NilValue); is_nil {
+			return rv, nil
+		}
+		o, ok := v.(*HostObject)
+		if !ok || o.v.Kind() != reflect.Struct || o.v.Addr().Type() != t {
+			return rv, mismatch
+		}
+		return o.v.Addr(), nil
+	case reflect.Struct:
+		o, ok := v.(*HostObject)
+		if !ok || o.v.Type() != t {
+			return rv, mismatch
+		}
+		rv.Set(o.v)
+	default:
+		return rv, fmt.Errorf("laks cannot use values of type %v", t)
+	}
+	return rv, nil
+}
+
+// map_to converts an object, or a module's members, to a map of type t.
+func map_to(v Value, t reflect.Type, mismatch error) (reflect.Value, error) {
+	var members map[string]Value
+	switch v := v.(type) {
+	case NilValue:
+		return reflect.Zero(t), nil
+	case *HostObject:
+		if v.v.Type() == t {
+			return v.v, nil
+		}
+		if v.v.Kind() != reflect.Map {
+			return reflect.Value{}, mismatch
+		}
+		members = map[string]Value{}
+		for _, k := range v.v.MapKeys() {
+			mv, err := ToValue(v.v.MapIndex(k).Interface())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			members[k.String()] = mv
+		}
+	case *ModuleValue:
+		members = v.Members
+	default:
+		return reflect.Value{}, mismatch
+	}
+
+	m := reflect.MakeMapWithSize(t, len(members))
+	for _, k := range slices.Sorted(maps.Keys(members)) {
+		ev, err := value_to(members[k], t.Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key '%s'. %w", k, err)
+		}
+		m.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), ev)
+	}
+	return m, nil
+}
+
+// field_name is the name scripts use for a struct field.
+func field_name(f reflect.StructField) string {
+	if tag := f.Tag.Get("laks"); tag != "" {
+		return tag
+	}
+	return script_name(f.Name)
+}
+
+// script_name turns a Go name into snake_case, keeping initialisms
+// together so that UserID is user_id and HTTPServer is http_server.
+func script_name(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			starts_word := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+			if starts_word {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,272 @@
+package laks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type test_user struct {
+	Name  string
+	Admin bool
+}
+
+type test_page struct {
+	Size int
+}
+
+type test_request struct {
+	Method  string
+	UserID  int64
+	Score   float64
+	Tags    []string
+	Headers map[string]string
+	Pages   map[string]test_page
+	User    *test_user
+	Page    test_page
+	Limit   uint8 `laks:"max"`
+	secret  string
+}
+
+func (r *test_request) Header(name string) string {
+	return r.Headers[name]
+}
+
+func (r *test_request) SetScore(s float64) {
+	r.Score = s
+}
+
+func (r *test_request) Check() error {
+	if r.Method == "" {
+		return errors.New("no method")
+	}
+	return nil
+}
+
+func (r *test_request) Join(sep string, parts ...string) (string, error) {
+	return strings.Join(parts, sep), nil
+}
+
+func new_test_request() *test_request {
+	return &test_request{
+		Method:  "GET",
+		UserID:  7,
+		Tags:    []string{"a", "b"},
+		Headers: map[string]string{"host": "example.com"},
+		Pages:   map[string]test_page{"first": {Size: 10}},
+		User:    &test_user{Name: "ann"},
+		secret:  "hidden",
+	}
+}
+
+func TestSetGlobal(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print req.method, req.user_id, req.tags, req.max;`, "GET 7 [\"a\", \"b\"] 0\n"},
+		{`print req.headers.host, req.user.name, req.user.admin;`, "example.com ann false\n"},
+		{`print req.header("host"), req.join("-", "x", "y"), req.join(",");`, "example.com x-y \n"},
+		{`print req, type(req), req == req, req.user == req.user;`, "<test_request> object true true\n"},
+		{`req.method = "POST"; req.max = 255; print req.method, req.max;`, "POST 255\n"},
+		{`req.set_score(2); print req.score; req.score = 1; print req.score;`, "2.0\n1.0\n"},
+		{`req.user.admin = true; req.headers.accept = "json"; print req.user.admin, req.headers.accept;`, "true json\n"},
+		{`req.tags = split("x,y", ","); print len(req.tags);`, "2\n"},
+		{`print req.check();`, "nil\n"},
+		{`req.page.size = 5; print req.page.size, req.page;`, "5 <test_page>\n"},
+		{`print req.pages.first.size, req.pages.first;`, "10 <test_page>\n"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			var w bytes.Buffer
+			in := NewInterpreter(&w)
+			err := in.SetGlobal("req", new_test_request())
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			_, err = in.Eval([]byte(tst.in))
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, w.String()); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetGlobalShares(t *testing.T) {
+	req := new_test_request()
+	in := NewInterpreter(&bytes.Buffer{})
+	if err := in.SetGlobal("req", req); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	_, err := in.Eval([]byte(`req.method = "PUT"; req.user.name = "bo"; req.page.size = 20; req.pages.second = req.page; req.headers.x = "1"; req.tags = split("c", ",");`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want := new_test_request()
+	want.Method = "PUT"
+	want.User.Name = "bo"
+	want.Page.Size = 20
+	want.Pages["second"] = test_page{Size: 20}
+	want.Headers["x"] = "1"
+	want.Tags = []string{"c"}
+	if diff := cmp.Diff(want, req, cmp.AllowUnexported(test_request{})); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+
+	v, err := in.Eval([]byte(`req;`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if FromValue(v) != any(req) {
+		t.Errorf("wanted the bound pointer back but got %v", FromValue(v))
+	}
+}
+
+func TestSetGlobalRuntimeErrors(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print req.secret;`, "1:1: test_request has no attribute 'secret'"},
+		{`print req.methd;`, "1:1: test_request has no attribute 'methd'. did you mean 'method'?"},
+		{`req.method = 1;`, "1:1: cannot set 'method'. wants string but got int"},
+		{`req.max = 256;`, "1:1: cannot set 'max'. 256 does not fit in uint8"},
+		{`req.tags = split("a", ",") + 1;`, "1:1: cannot apply '+' to list and int"},
+		{`req.nope = 1;`, "1:1: test_request has no field 'nope'"},
+		{`req.pages.first.size = 5;`, "1:1: cannot set 'size'. this test_page is a copy of one stored in a map. set the map's key to a new value instead"},
+		{`print req.headers.missing;`, "1:1: map[string]string has no key 'missing'"},
+		{`req.method = ""; req.check();`, "1:18: error calling test_request.check. no method"},
+		{`req.header(1);`, "1:1: error calling test_request.header. argument 1. wants string but got int"},
+		{`req.header();`, "1:1: test_request.header wants 1 arguments but got 0"},
+		{`math.pi = 3;`, "1:1: cannot set attribute 'pi' of '<module math>'"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			in := NewInterpreter(&bytes.Buffer{})
+			if err := in.SetGlobal("req", new_test_request()); err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			_, err := in.Eval([]byte(tst.in))
+			var re *RuntimeError
+			if !errors.As(err, &re) || re.Error() != tst.want {
+				tt.Errorf("wanted %q but got %v", tst.want, err)
+			}
+		})
+	}
+}
+
+type bad_field struct {
+	Done chan bool
+}
+
+type bad_method struct{}
+
+func (*bad_method) Pair() (int, int, error) { return 0, 0, nil }
+
+type bad_nested struct {
+	Inner []map[int]string
+}
+
+type recursive struct {
+	Name string
+	Next *recursive
+}
+
+type test_node struct {
+	Value int
+	next  *test_node
+}
+
+func (n *test_node) Next() *test_node { return n.next }
+
+type test_builder struct {
+	Parts []string
+}
+
+func (b *test_builder) Add(part string) *test_builder {
+	b.Parts = append(b.Parts, part)
+	return b
+}
+
+func (b *test_builder) Done() *test_result { return &test_result{len(b.Parts)} }
+
+type test_result struct {
+	Count int
+}
+
+func (r *test_result) Again() *test_builder { return &test_builder{} }
+
+func TestSetGlobalSelfReferences(t *testing.T) {
+	var w bytes.Buffer
+	in := NewInterpreter(&w)
+	if err := in.SetGlobal("n", &test_node{1, &test_node{2, nil}}); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if err := in.SetGlobal("b", &test_builder{}); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	_, err := in.Eval([]byte(`print n.next().value; print b.add("x").add("y").done().count;`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if diff := cmp.Diff("2\n2\n", w.String()); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSetGlobalBindErrors(t *testing.T) {
+	var tests = []struct {
+		in   any
+		want string
+	}{
+		{&bad_field{}, "cannot bind 'x'. field laks.bad_field.Done. laks cannot use values of type chan bool"},
+		{&bad_method{}, "cannot bind 'x'. method laks.bad_method.Pair. returns 3 values. only a value, an error, or both can be returned"},
+		{bad_nested{}, "cannot bind 'x'. field laks.bad_nested.Inner. map keys must be strings but map[int]string has int keys"},
+		{func() {}, "cannot bind 'x'. laks cannot use values of type func()"},
+		{&recursive{}, ""},
+		{[]any{1, "a"}, ""},
+		{nil, ""},
+	}
+
+	for _, tst := range tests {
+		t.Run(fmt.Sprintf("%T", tst.in), func(tt *testing.T) {
+			err := NewInterpreter(&bytes.Buffer{}).SetGlobal("x", tst.in)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tst.want {
+				tt.Errorf("wanted %q but got %q", tst.want, got)
+			}
+		})
+	}
+}
+
+func TestScriptName(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{"Name", "name"},
+		{"UserID", "user_id"},
+		{"HTTPServer", "http_server"},
+		{"SetScore", "set_score"},
+		{"Name2", "name2"},
+		{"ID", "id"},
+	}
+
+	for _, tst := range tests {
+		if got := script_name(tst.in); got != tst.want {
+			t.Errorf("%s: wanted %q but got %q", tst.in, tst.want, got)
+		}
+	}
+}
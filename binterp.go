@@ -1,9 +1,17 @@
 package laks
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 //go:generate stringer -type=ValueType
@@ -14,13 +22,73 @@ const (
 	VAL_TRUE
 	VAL_FALSE
 	VAL_STRING
+	VAL_FLOAT
+	VAL_NIL
+	VAL_LIST
+	VAL_FUNCTION
+	VAL_MODULE
+	VAL_OBJECT
 )
 
+// value_type gives the ValueType of any value, including those such as lists
+// that only exist at runtime.
+func value_type(v Value) ValueType {
+	switch v.(type) {
+	case IntValue:
+		return VAL_INT
+	case TrueValue:
+		return VAL_TRUE
+	case FalseValue:
+		return VAL_FALSE
+	case StringValue:
+		return VAL_STRING
+	case FloatValue:
+		return VAL_FLOAT
+	case ListValue:
+		return VAL_LIST
+	case *NativeFunction:
+		return VAL_FUNCTION
+	case *ModuleValue:
+		return VAL_MODULE
+	case *HostObject:
+		return VAL_OBJECT
+	default:
+		return VAL_NIL
+	}
+}
+
+// type_name is the name scripts see for a ValueType.
+func type_name(t ValueType) string {
+	switch t {
+	case VAL_TRUE, VAL_FALSE:
+		return "bool"
+	default:
+		return strings.ToLower(strings.TrimPrefix(t.String(), "VAL_"))
+	}
+}
+
 type Value any
 type IntValue int64
 type TrueValue bool
 type FalseValue bool
 type StringValue string
+type ListValue []Value
+type FloatValue float64
+type NilValue struct{}
+
+// ModuleValue groups related values, such as the natives of a library, under
+// one name. Members are read with the '.' operator.
+type ModuleValue struct {
+	Name    string
+	Members map[string]Value
+}
+
+// NativeFunction is a function implemented in Go that scripts can call.
+type NativeFunction struct {
+	Name  string
+	Arity int // -1 accepts any number of arguments
+	Fn    func(args []Value) (Value, error)
+}
 
 type stack []Value
 
@@ -37,134 +105,680 @@ func (s *stack) pop() Value {
 
 type bytecode_interpreter struct {
 	ip        int
+	op_ip     int // where the instruction being run starts
 	bytecode  []byte
+	constants []Value
+	lines     LineTable
 	w         io.Writer
 	val_stack stack
+	globals   map[string]Value
+	frames    []frame
 }
 
-func Run(bytecode []byte, w io.Writer) error {
-	bi := bytecode_interpreter{bytecode: bytecode, w: w}
+// frame is an active call to a function.
+type frame struct {
+	name    string
+	call_ip int
+}
+
+func Run(bytecode []byte, w io.Writer, opts ...Option) error {
+	bi := new_interpreter(bytecode, w, new_config(opts))
 	return bi.run()
 }
 
-func (bi *bytecode_interpreter) run() error {
+// RunProgram runs a compiled program. Runtime errors say where in the
+// source they happened if the program has debug info.
+func RunProgram(p *Program, w io.Writer, opts ...Option) error {
+	bi := new_interpreter(nil, w, new_config(opts))
+	bi.load(p)
+	return bi.run()
+}
+
+// load makes p the program to run, from its start.
+func (bi *bytecode_interpreter) load(p *Program) {
+	bi.bytecode = p.Code
+	bi.constants = p.Constants
+	bi.lines = nil
+	if p.Debug != nil {
+		bi.lines = p.Debug.Lines
+	}
+	bi.ip = 0
+}
+
+func new_interpreter(bytecode []byte, w io.Writer, c config) *bytecode_interpreter {
+	bi := &bytecode_interpreter{
+		bytecode: bytecode,
+		w:        w,
+		globals:  make(map[string]Value),
+	}
+	bi.register(string_functions...)
+	input, ok := c.input.(*bufio.Reader)
+	if !ok {
+		input = bufio.NewReader(c.input)
+	}
+	bi.register(input_functions(input, w)...)
+	bi.register(file_functions(c)...)
+	bi.register(format_functions(w)...)
+	bi.register(conversion_functions...)
+	bi.register(process_functions...)
+	bi.globals["math"] = math_module()
+	args := make(ListValue, len(c.args))
+	for i, arg := range c.args {
+		args[i] = StringValue(arg)
+	}
+	bi.globals["args"] = args
+	bi.register(c.natives...)
+	return bi
+}
+
+// register makes native functions available to scripts as globals.
+func (bi *bytecode_interpreter) register(fns ...*NativeFunction) {
+	for _, fn := range fns {
+		bi.globals[fn.Name] = fn
+	}
+}
+
+// run runs the bytecode to the end, or until the script calls exit, turning
+// any failure into a *RuntimeError. A native that panics is reported the
+// same way, so a bad script can never take down its host.
+func (bi *bytecode_interpreter) run() (err error) {
+	bi.frames = bi.frames[:0]
+	defer func() {
+		if r := recover(); r != nil {
+			err = bi.runtime_error(fmt.Errorf("internal error: %v", r))
+		}
+	}()
+
+	err = bi.execute()
+	if err == nil {
+		return nil
+	}
+	switch err := err.(type) {
+	case *RuntimeError:
+		return err
+	case *ExitError:
+		return err
+	}
+	return bi.runtime_error(err)
+}
+
+// runtime_error records where err happened and the calls that were active.
+func (bi *bytecode_interpreter) runtime_error(err error) *RuntimeError {
+	re := &RuntimeError{Msg: err.Error(), Err: err}
+	var he *hinted_error
+	if errors.As(err, &he) {
+		re.Hint = he.hint
+	}
+	re.Pos, _ = bi.lines.Lookup(bi.op_ip)
+	if bi.op_ip < len(bi.bytecode) {
+		re.Op = OpCode(bi.bytecode[bi.op_ip])
+	}
+	var oe *operand_error
+	if errors.As(err, &oe) {
+		for _, v := range oe.operands {
+			re.Operands = append(re.Operands, type_name(value_type(v)))
+		}
+	}
+
+	ip := bi.op_ip
+	for i := len(bi.frames) - 1; i >= 0; i-- {
+		// Natives have no source position.
+		re.Trace = append(re.Trace, Frame{Name: bi.frames[i].name})
+		ip = bi.frames[i].call_ip
+	}
+	pos, _ := bi.lines.Lookup(ip)
+	re.Trace = append(re.Trace, Frame{main_frame, pos})
+	return re
+}
+
+func (bi *bytecode_interpreter) execute() error {
 	for bi.ip < len(bi.bytecode) {
-		code_id := bi.read()
+		bi.op_ip = bi.ip
+		code_id, _ := bi.read()
+		var err error
 		switch code_id {
 		case byte(OP_PUSH):
-			bi.push_val()
+			err = bi.push_val()
+		case byte(OP_CONST):
+			err = bi.push_const()
+		case byte(OP_RETURN):
+			return nil
 		case byte(OP_MULT):
-			bi.mult()
+			err = bi.arith(
+				func(x, y int64) (int64, error) { return x * y, nil },
+				func(x, y float64) float64 { return x * y },
+			)
 		case byte(OP_PRINT):
-			bi.print()
+			err = bi.print()
+		case byte(OP_PRINTN):
+			err = bi.write()
+			if err == nil {
+				fmt.Fprintln(bi.w)
+			}
+		case byte(OP_WRITE):
+			err = bi.write()
+		case byte(OP_POP):
+			_, err = bi.pop()
+		case byte(OP_ASSERT):
+			err = bi.assert()
+		case byte(OP_ASSERT_CMP):
+			err = bi.assert_cmp()
 		case byte(OP_ADD):
-			bi.add()
+			err = bi.add()
 		case byte(OP_DIV):
-			bi.div()
+			err = bi.arith(
+				func(x, y int64) (int64, error) {
+					if y == 0 {
+						return 0, errors.New("division by zero")
+					}
+					return x / y, nil
+				},
+				func(x, y float64) float64 { return x / y },
+			)
 		case byte(OP_MINUS):
-			bi.minus()
+			err = bi.arith(
+				func(x, y int64) (int64, error) { return x - y, nil },
+				func(x, y float64) float64 { return x - y },
+			)
 		case byte(OP_EQ):
-			bi.eq()
+			err = bi.eq()
+		case byte(OP_GET_GLOBAL):
+			err = bi.get_global()
+		case byte(OP_CALL):
+			err = bi.call()
+		case byte(OP_NEGATE):
+			err = bi.negate()
+		case byte(OP_GET_ATTR):
+			err = bi.get_attr()
+		case byte(OP_SET_ATTR):
+			err = bi.set_attr()
 		default:
-			return fmt.Errorf("could not decode byte code '%v'", code_id)
+			err = fmt.Errorf("could not decode byte code '%v'", code_id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pop takes the top value off the stack. Only bad bytecode can underflow
+// the stack, but that is reported rather than crashing the host.
+func (bi *bytecode_interpreter) pop() (Value, error) {
+	if len(bi.val_stack) == 0 {
+		return nil, errors.New("stack underflow")
+	}
+	return bi.val_stack.pop(), nil
+}
+
+// pop_n takes the top n values off the stack, in the order they were
+// pushed.
+func (bi *bytecode_interpreter) pop_n(n int) ([]Value, error) {
+	if len(bi.val_stack) < n {
+		return nil, errors.New("stack underflow")
+	}
+	vals := slices.Clone(bi.val_stack[len(bi.val_stack)-n:])
+	bi.val_stack = bi.val_stack[:len(bi.val_stack)-n]
+	return vals, nil
+}
+
+func (bi *bytecode_interpreter) get_global() error {
+	name, err := bi.read_string()
+	if err != nil {
+		return err
+	}
+	v, ok := bi.globals[name]
+	if !ok {
+		err := fmt.Errorf("undefined name '%s'", name)
+		return with_hint(err, did_you_mean(name, slices.Collect(maps.Keys(bi.globals))))
+	}
+	bi.val_stack.push(v)
+	return nil
+}
+
+func (bi *bytecode_interpreter) get_attr() error {
+	name, err := bi.read_string()
+	if err != nil {
+		return err
+	}
+	obj, err := bi.pop()
+	if err != nil {
+		return err
+	}
+
+	if o, ok := obj.(*HostObject); ok {
+		v, err := o.get(name)
+		if err != nil {
+			return err
 		}
+		bi.val_stack.push(v)
+		return nil
 	}
+	m, ok := obj.(*ModuleValue)
+	if !ok {
+		return operand_errorf([]Value{obj}, "cannot read attribute '%s' of '%s'", name, format_value(obj))
+	}
+	v, ok := m.Members[name]
+	if !ok {
+		err := fmt.Errorf("module %s has no member '%s'", m.Name, name)
+		return with_hint(err, did_you_mean(name, slices.Collect(maps.Keys(m.Members))))
+	}
+	bi.val_stack.push(v)
 	return nil
 }
 
-func (bi *bytecode_interpreter) minus() {
-	a := bi.val_stack.pop()
-	b := bi.val_stack.pop()
-	bi.val_stack.push(IntValue(b.(IntValue) - a.(IntValue)))
+// set_attr sets an attribute of an object bound from Go, which are the only
+// values whose attributes can be set.
+func (bi *bytecode_interpreter) set_attr() error {
+	name, err := bi.read_string()
+	if err != nil {
+		return err
+	}
+	vals, err := bi.pop_n(2)
+	if err != nil {
+		return err
+	}
+	o, ok := vals[0].(*HostObject)
+	if !ok {
+		return operand_errorf(vals[:1], "cannot set attribute '%s' of '%s'", name, format_value(vals[0]))
+	}
+	return o.set(name, vals[1])
 }
 
-func (bi *bytecode_interpreter) div() {
-	a := bi.val_stack.pop()
-	b := bi.val_stack.pop()
-	if a.(IntValue) == 0 {
-		panic("divide by zero")
+func (bi *bytecode_interpreter) call() error {
+	argc, err := bi.read()
+	if err != nil {
+		return err
+	}
+	vals, err := bi.pop_n(int(argc) + 1)
+	if err != nil {
+		return err
+	}
+	callee, args := vals[0], vals[1:]
+
+	fn, ok := callee.(*NativeFunction)
+	if !ok {
+		return operand_errorf([]Value{callee}, "cannot call '%s'", format_value(callee))
 	}
-	bi.val_stack.push(IntValue(b.(IntValue) / a.(IntValue)))
+	if fn.Arity >= 0 && fn.Arity != len(args) {
+		return fmt.Errorf("%s wants %d arguments but got %d", fn.Name, fn.Arity, len(args))
+	}
+	bi.frames = append(bi.frames, frame{fn.Name, bi.op_ip})
+	v, err := call_native(fn, args)
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return exit
+	}
+	if err != nil {
+		return bi.runtime_error(fmt.Errorf("error calling %s. %w", fn.Name, err))
+	}
+	bi.frames = bi.frames[:len(bi.frames)-1]
+	if v == nil {
+		v = NilValue{}
+	}
+	bi.val_stack.push(v)
+	return nil
 }
 
-func (bi *bytecode_interpreter) add() {
-	a := bi.val_stack.pop()
-	b := bi.val_stack.pop()
+// call_native calls fn, turning a panic into an error so that it is
+// reported against fn like any error fn returns.
+func call_native(fn *NativeFunction, args []Value) (v Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return fn.Fn(args)
+}
+
+func (bi *bytecode_interpreter) add() error {
+	vals, err := bi.pop_n(2)
+	if err != nil {
+		return err
+	}
+	a, aIsString := vals[0].(StringValue)
+	b, bIsString := vals[1].(StringValue)
+	if aIsString && bIsString {
+		bi.val_stack.push(a + b)
+		return nil
+	}
+	bi.val_stack.push(vals[0])
+	bi.val_stack.push(vals[1])
+	return bi.arith(
+		func(x, y int64) (int64, error) { return x + y, nil },
+		func(x, y float64) float64 { return x + y },
+	)
+}
 
-	switch a.(type) {
+func (bi *bytecode_interpreter) negate() error {
+	a, err := bi.pop()
+	if err != nil {
+		return err
+	}
+	switch v := a.(type) {
 	case IntValue:
-		bi.val_stack.push(IntValue(a.(IntValue) + b.(IntValue)))
-	case StringValue:
-		bi.val_stack.push(StringValue(b.(StringValue) + a.(StringValue)))
+		bi.val_stack.push(-v)
+	case FloatValue:
+		bi.val_stack.push(-v)
+	default:
+		return operand_errorf([]Value{a}, "cannot apply '-' to %s", type_name(value_type(a)))
+	}
+	return nil
+}
+
+// arith applies the arithmetic operator of the current instruction to the
+// top two values. Two ints give an int; if either operand is a float both
+// are promoted and the result is a float.
+func (bi *bytecode_interpreter) arith(intop func(x, y int64) (int64, error), floatop func(x, y float64) float64) error {
+	vals, err := bi.pop_n(2)
+	if err != nil {
+		return err
+	}
+	a, b := vals[0], vals[1]
+	if !is_number(a) || !is_number(b) {
+		return operand_errorf(vals, "cannot apply '%s' to %s and %s",
+			op_symbol(OpCode(bi.bytecode[bi.op_ip])), type_name(value_type(a)), type_name(value_type(b)))
+	}
+
+	x, aIsInt := a.(IntValue)
+	y, bIsInt := b.(IntValue)
+	if aIsInt && bIsInt {
+		v, err := intop(int64(x), int64(y))
+		if err != nil {
+			return operand_errorf(vals, "%v", err)
+		}
+		bi.val_stack.push(IntValue(v))
+		return nil
 	}
+	bi.val_stack.push(FloatValue(floatop(to_float(a), to_float(b))))
+	return nil
+}
 
+// op_symbol is how an arithmetic opcode is written in source.
+func op_symbol(op OpCode) string {
+	switch op {
+	case OP_ADD:
+		return "+"
+	case OP_MINUS, OP_NEGATE:
+		return "-"
+	case OP_MULT:
+		return "*"
+	case OP_DIV:
+		return "/"
+	default:
+		return op.String()
+	}
 }
 
-func (bi *bytecode_interpreter) print() {
-	v := bi.val_stack.pop()
-	switch v.(type) {
-	case IntValue, StringValue:
-		fmt.Fprintf(bi.w, "%v\n", v)
+// to_float converts a value that is_number to a float.
+func to_float(v Value) float64 {
+	switch v := v.(type) {
+	case IntValue:
+		return float64(v)
+	case FloatValue:
+		return float64(v)
+	default:
+		return math.NaN()
+	}
+}
+
+func (bi *bytecode_interpreter) print() error {
+	v, err := bi.pop()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(bi.w, format_value(v))
+	return nil
+}
+
+func (bi *bytecode_interpreter) assert() error {
+	vals, err := bi.pop_n(2)
+	if err != nil {
+		return err
+	}
+	msg, v := vals[0], vals[1]
+	if !is_truthy(v) {
+		return fmt.Errorf("assertion failed: %s", format_value(msg))
+	}
+	return nil
+}
+
+func (bi *bytecode_interpreter) assert_cmp() error {
+	b, err := bi.read()
+	if err != nil {
+		return err
+	}
+	op := BinaryOperator(b)
+	vals, err := bi.pop_n(3)
+	if err != nil {
+		return err
+	}
+	msg, left, right := vals[0], vals[1], vals[2]
+
+	var ok bool
+	switch op {
+	case BO_EQ:
+		ok = values_equal(left, right)
+	default:
+		return fmt.Errorf("cannot assert with operator '%v'", op)
+	}
+	if !ok {
+		return fmt.Errorf("assertion failed: %s. left is %s, right is %s", format_value(msg), repr_value(left), repr_value(right))
+	}
+	return nil
+}
+
+// write outputs the top n values separated by spaces, where n is the
+// instruction's operand.
+func (bi *bytecode_interpreter) write() error {
+	n, err := bi.read()
+	if err != nil {
+		return err
+	}
+	vals, err := bi.pop_n(int(n))
+	if err != nil {
+		return err
+	}
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		strs[i] = format_value(v)
+	}
+	fmt.Fprint(bi.w, strings.Join(strs, " "))
+	return nil
+}
+
+// repr_value is like format_value but quotes strings, for showing values
+// where their type matters.
+func repr_value(v Value) string {
+	if s, ok := v.(StringValue); ok {
+		return strconv.Quote(string(s))
+	}
+	return format_value(v)
+}
+
+func format_value(v Value) string {
+	switch v := v.(type) {
 	case TrueValue:
-		fmt.Fprintln(bi.w, "true")
+		return "true"
 	case FalseValue:
-		fmt.Fprintln(bi.w, "false")
+		return "false"
+	case NilValue:
+		return "nil"
+	case ListValue:
+		var sb strings.Builder
+		sb.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(repr_value(e))
+		}
+		sb.WriteByte(']')
+		return sb.String()
+	case FloatValue:
+		f := strconv.FormatFloat(float64(v), 'g', -1, 64)
+		if !strings.ContainsAny(f, ".eIN") {
+			f += ".0"
+		}
+		return f
+	case *NativeFunction:
+		return fmt.Sprintf("<native %s>", v.Name)
+	case *ModuleValue:
+		return fmt.Sprintf("<module %s>", v.Name)
+	case *HostObject:
+		return fmt.Sprintf("<%s>", v.type_name())
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func (bi *bytecode_interpreter) eq() error {
+	vals, err := bi.pop_n(2)
+	if err != nil {
+		return err
+	}
+	bi.val_stack.push(bool_value(values_equal(vals[0], vals[1])))
+	return nil
+}
+
+func values_equal(a, b Value) bool {
+	_, aIsInt := a.(IntValue)
+	_, bIsInt := b.(IntValue)
+	if is_number(a) && is_number(b) && aIsInt != bIsInt {
+		return to_float(a) == to_float(b)
+	}
+	if ao, ok := a.(*HostObject); ok {
+		bo, ok := b.(*HostObject)
+		return ok && ao.same(bo)
+	}
+	al, ok := a.(ListValue)
+	if !ok {
+		_, bIsList := b.(ListValue)
+		return !bIsList && a == b
+	}
+	bl, ok := b.(ListValue)
+	if !ok || len(al) != len(bl) {
+		return false
+	}
+	for i := range al {
+		if !values_equal(al[i], bl[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// is_truthy decides whether a value counts as true. false, nil, zero, and
+// empty strings and lists are false and everything else is true.
+func is_truthy(v Value) bool {
+	switch v := v.(type) {
+	case FalseValue, NilValue:
+		return false
+	case IntValue:
+		return v != 0
+	case FloatValue:
+		return v != 0
+	case StringValue:
+		return v != ""
+	case ListValue:
+		return len(v) != 0
 	default:
-		fmt.Fprintf(bi.w, "%v\n", v)
+		return true
+	}
+}
 
+func is_number(v Value) bool {
+	switch v.(type) {
+	case IntValue, FloatValue:
+		return true
+	default:
+		return false
 	}
 }
 
-func (bi *bytecode_interpreter) mult() {
-	a := bi.val_stack.pop()
-	b := bi.val_stack.pop()
-	bi.val_stack.push(IntValue(a.(IntValue) * b.(IntValue)))
+func bool_value(b bool) Value {
+	if b {
+		return TrueValue(true)
+	}
+	return FalseValue(false)
 }
 
-func (bi *bytecode_interpreter) eq() {
-	a := bi.val_stack.pop()
-	b := bi.val_stack.pop()
+func (bi *bytecode_interpreter) push_val() error {
+	v, n, err := decode_value(bi.bytecode[bi.ip:])
+	if err != nil {
+		return err
+	}
+	bi.ip += n
+	bi.val_stack.push(v)
+	return nil
+}
 
-	if a == b {
-		bi.val_stack.push(TrueValue(true))
-	} else {
-		bi.val_stack.push(FalseValue(false))
+func (bi *bytecode_interpreter) push_const() error {
+	if bi.ip+2 > len(bi.bytecode) {
+		return errors.New("missing constant index")
+	}
+	i := int(binary.LittleEndian.Uint16(bi.bytecode[bi.ip:]))
+	bi.ip += 2
+	if i >= len(bi.constants) {
+		return fmt.Errorf("constant %d is not in the pool of %d", i, len(bi.constants))
 	}
+	bi.val_stack.push(bi.constants[i])
+	return nil
 }
 
-func (bi *bytecode_interpreter) push_val() {
-	val_byte := bi.read()
-	switch val_byte {
-	case byte(VAL_INT):
+// decode_value reads a constant written by appendValue, returning it and
+// how many bytes it took up.
+func decode_value(b []byte) (Value, int, error) {
+	if len(b) == 0 {
+		return nil, 0, fmt.Errorf("missing value type")
+	}
+	switch ValueType(b[0]) {
+	case VAL_INT:
 		var d int64
-		read, err := binary.Decode(bi.bytecode[bi.ip:], binary.LittleEndian, &d)
+		read, err := binary.Decode(b[1:], binary.LittleEndian, &d)
 		if err != nil {
-			panic(err)
+			return nil, 0, fmt.Errorf("could not decode int. %v", err)
 		}
-		bi.ip += read
-		bi.val_stack = append(bi.val_stack, IntValue(int64(d)))
-	case byte(VAL_TRUE):
-		bi.val_stack = append(bi.val_stack, TrueValue(true))
-	case byte(VAL_FALSE):
-		bi.val_stack = append(bi.val_stack, FalseValue(false))
-	case byte(VAL_STRING):
-		start := bi.ip
-		end := start
-		for {
-			b := bi.read()
-			if b == 0 {
-				break
-			}
-			end++
+		return IntValue(d), 1 + read, nil
+	case VAL_FLOAT:
+		var f float64
+		read, err := binary.Decode(b[1:], binary.LittleEndian, &f)
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not decode float. %v", err)
 		}
-		bi.val_stack = append(bi.val_stack, StringValue(string(bi.bytecode[start:end])))
+		return FloatValue(f), 1 + read, nil
+	case VAL_TRUE:
+		return TrueValue(true), 1, nil
+	case VAL_FALSE:
+		return FalseValue(false), 1, nil
+	case VAL_NIL:
+		return NilValue{}, 1, nil
+	case VAL_STRING:
+		end := bytes.IndexByte(b[1:], 0)
+		if end < 0 {
+			return nil, 0, fmt.Errorf("unterminated string")
+		}
+		return StringValue(b[1 : 1+end]), 2 + end, nil
 	default:
-		panic(fmt.Sprintf("Could not convert '%v' to ValueType", val_byte))
+		return nil, 0, fmt.Errorf("could not convert '%v' to ValueType", b[0])
 	}
 }
 
-func (bi *bytecode_interpreter) read() byte {
+func (bi *bytecode_interpreter) read_string() (string, error) {
+	end := bytes.IndexByte(bi.bytecode[bi.ip:], 0)
+	if end < 0 {
+		return "", errors.New("unterminated string in bytecode")
+	}
+	s := string(bi.bytecode[bi.ip : bi.ip+end])
+	bi.ip += end + 1
+	return s, nil
+}
+
+func (bi *bytecode_interpreter) read() (byte, error) {
+	if bi.ip >= len(bi.bytecode) {
+		return 0, errors.New("unexpected end of bytecode")
+	}
 	b := bi.bytecode[bi.ip]
 	bi.ip++
-	return b
+	return b, nil
 }
@@ -2,6 +2,7 @@ package laks
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -45,6 +46,148 @@ func Test_VM(t *testing.T) {
 	}
 }
 
+func TestExpressionStatementsBalanceStack(t *testing.T) {
+	tokens, err := Tokenise([]byte(`1 + 2; "a" == "b"; len("abc"); print 4;`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	stmts, err := Parse(tokens)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	bytecode, err := Compile(stmts)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	var w bytes.Buffer
+	bi := new_interpreter(bytecode, &w, new_config(nil))
+	err = bi.run()
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if len(bi.val_stack) != 0 {
+		t.Errorf("wanted an empty stack but got %v", bi.val_stack)
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	var tests = []struct {
+		in       string
+		want     string
+		op       OpCode
+		operands []string
+	}{
+		{`print 1 + "a";`, "1:1: cannot apply '+' to int and string", OP_ADD, []string{"int", "string"}},
+		{`print 1 / 0;`, "1:1: division by zero", OP_DIV, []string{"int", "int"}},
+		{`print -"a";`, "1:1: cannot apply '-' to string", OP_NEGATE, []string{"string"}},
+		{`print 2 * nil;`, "1:1: cannot apply '*' to int and nil", OP_MULT, []string{"int", "nil"}},
+		{`print "a" - 1.5;`, "1:1: cannot apply '-' to string and float", OP_MINUS, []string{"string", "float"}},
+		{`print len.x;`, "1:1: cannot read attribute 'x' of '<native len>'", OP_GET_ATTR, []string{"function"}},
+		{`print true(2);`, "1:1: cannot call 'true'", OP_CALL, []string{"bool"}},
+		{`print nope;`, "1:1: undefined name 'nope'", OP_GET_GLOBAL, nil},
+		{`print lne("a");`, "1:1: undefined name 'lne'. did you mean 'len'?", OP_GET_GLOBAL, nil},
+		{`print math.sqr(4);`, "1:1: module math has no member 'sqr'. did you mean 'sqrt'?", OP_GET_ATTR, nil},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			err := RunBytes([]byte(tst.in), &bytes.Buffer{})
+			var re *RuntimeError
+			if !errors.As(err, &re) {
+				tt.Fatalf("wanted a *RuntimeError but got %v", err)
+			}
+			if re.Error() != tst.want {
+				tt.Errorf("wanted %q but got %q", tst.want, re.Error())
+			}
+			if re.Op != tst.op {
+				tt.Errorf("wanted op %v but got %v", tst.op, re.Op)
+			}
+			if diff := cmp.Diff(tst.operands, re.Operands); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFloatDivisionByZero(t *testing.T) {
+	var out bytes.Buffer
+	err := RunBytes([]byte(`print 1.5 / 0;`), &out)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if out.String() != "+Inf\n" {
+		t.Errorf("wanted +Inf but got %q", out.String())
+	}
+}
+
+func TestMalformedBytecode(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   []byte
+	}{
+		{"unknown opcode", []byte{255}},
+		{"stack underflow", []byte{byte(OP_POP)}},
+		{"binary underflow", []byte{byte(OP_PUSH), byte(VAL_NIL), byte(OP_ADD)}},
+		{"print underflow", []byte{byte(OP_PRINTN), 3}},
+		{"truncated int", []byte{byte(OP_PUSH), byte(VAL_INT), 1, 2}},
+		{"missing value type", []byte{byte(OP_PUSH)}},
+		{"unknown value type", []byte{byte(OP_PUSH), 200}},
+		{"unterminated string", []byte{byte(OP_GET_GLOBAL), 'a', 'b'}},
+		{"missing argument count", []byte{byte(OP_CALL)}},
+		{"missing constant index", []byte{byte(OP_CONST), 0}},
+		{"constant not in pool", []byte{byte(OP_CONST), 0, 0}},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			err := Run(tst.in, &bytes.Buffer{})
+			var re *RuntimeError
+			if !errors.As(err, &re) {
+				tt.Fatalf("wanted a *RuntimeError but got %v", err)
+			}
+		})
+	}
+}
+
+func TestNativePanic(t *testing.T) {
+	bytecode, err := CompileBytes([]byte(`explode();`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	bi := new_interpreter(bytecode, &bytes.Buffer{}, new_config(nil))
+	bi.register(&NativeFunction{"explode", 0, func(args []Value) (Value, error) {
+		panic("boom")
+	}})
+
+	err = bi.run()
+	var re *RuntimeError
+	if !errors.As(err, &re) || re.Msg != "error calling explode. internal error: boom" {
+		t.Errorf("wanted an internal error naming explode but got %v", err)
+	}
+}
+
+func TestBareCallStatements(t *testing.T) {
+	in := `
+mkdir("out");
+write_file("out/a.txt", "one");
+append_file("out/a.txt", ",two");
+printf("{}-{}", 1, 2);
+print "";
+print read_file("out/a.txt");
+remove("out/a.txt");
+print exists("out/a.txt");
+`
+	var w bytes.Buffer
+	err := RunBytes([]byte(in), &w, WithFileAccess(t.TempDir()))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if diff := cmp.Diff("1-2\none,two\nfalse\n", w.String()); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestStac(t *testing.T) {
 	var s stack
 	var i int64
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danwhitford/laks"
+)
+
+// run_build compiles a source file into a .lakc program file that laks can
+// run without the source.
+func run_build(args []string, stdout, stderr io.Writer) int {
+	flags := new_flags("build", "laks build [-o file.lakc] [-strip] file.lak", stderr)
+	out := flags.String("o", "", "write the program to this file instead of the source name with .lakc")
+	strip := flags.Bool("strip", false, "leave out debug info")
+	files, err := parse_interspersed(flags, args)
+	if err != nil {
+		return flag_exit(err)
+	}
+	if len(files) != 1 {
+		flags.Usage()
+		return exit_usage
+	}
+	src := files[0]
+	if *out == "" {
+		*out = strings.TrimSuffix(src, ".lak") + ".lakc"
+	}
+
+	b, err := os.ReadFile(src)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exit_usage
+	}
+	p, err := laks.BuildProgram(b, laks.WithModules(os.DirFS(filepath.Dir(src))), laks.WithSourceName(src))
+	if err != nil {
+		report_error(stderr, err, src, b, use_colour(stderr))
+		return exit_compile
+	}
+	if *strip {
+		p.Debug = nil
+	}
+	compiled, err := p.MarshalBinary()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exit_failure
+	}
+	if err := os.WriteFile(*out, compiled, 0644); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exit_failure
+	}
+	return 0
+}
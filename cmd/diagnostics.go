@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danwhitford/laks"
+)
+
+// report_error shows err as diagnostics against the source it points at.
+// src is the source of the file called name; diagnostics in other files,
+// such as imported modules, are shown against those files when they can
+// be read.
+func report_error(w io.Writer, err error, name string, src []byte, colour bool) {
+	for _, d := range laks.Diagnostics(err) {
+		file_src := src
+		if file := d.Span.Start.File; file != "" && file != name {
+			file_src, _ = os.ReadFile(file)
+		}
+		fmt.Fprint(w, laks.RenderDiagnostic(d, file_src, colour))
+	}
+
+	var re *laks.RuntimeError
+	if errors.As(err, &re) && len(re.Trace) > 1 {
+		fmt.Fprint(w, re.StackTrace())
+	}
+}
+
+// Exit codes, so that whoever ran laks can tell why it failed. A script
+// can also choose its own with exit(n).
+const (
+	exit_failure = 1 // the script failed while running, or tests failed
+	exit_usage   = 2 // laks was run with the wrong arguments or files
+	exit_compile = 3 // the script could not be tokenised, parsed or compiled
+)
+
+// exit_code is the exit code for the process after err.
+func exit_code(err error) int {
+	var exit *laks.ExitError
+	var syntax_errs laks.SyntaxErrors
+	var syntax_err *laks.SyntaxError
+	var compile_err *laks.CompileError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exit):
+		return exit.Code
+	case errors.As(err, &syntax_errs), errors.As(err, &syntax_err), errors.As(err, &compile_err):
+		return exit_compile
+	default:
+		return exit_failure
+	}
+}
+
+// report_exit reports err, unless it is just the script exiting, and
+// returns the exit code for it.
+func report_exit(w io.Writer, err error, name string, src []byte) int {
+	var exit *laks.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		report_error(w, err, name, src, use_colour(w))
+	}
+	return exit_code(err)
+}
+
+// use_colour reports whether output to w should be coloured: only for a
+// terminal, and not when NO_COLOR is set.
+func use_colour(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || !is_terminal(f) {
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+}
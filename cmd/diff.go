@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diff_line struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// unified_diff compares two texts line by line and renders the differences
+// in unified diff format with three lines of context. It returns "" when the
+// texts are the same.
+func unified_diff(from_name, to_name, from, to string) string {
+	a := split_lines(from)
+	b := split_lines(to)
+	lines := diff_lines(a, b)
+
+	var sb strings.Builder
+	const context = 3
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].op == ' ' {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = run
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from_name, to_name)
+		}
+		write_hunk(&sb, lines, start, end)
+		i = end
+	}
+	return sb.String()
+}
+
+func write_hunk(sb *strings.Builder, lines []diff_line, start, end int) {
+	from_start, to_start := 1, 1
+	for _, l := range lines[:start] {
+		if l.op != '+' {
+			from_start++
+		}
+		if l.op != '-' {
+			to_start++
+		}
+	}
+	from_len, to_len := 0, 0
+	for _, l := range lines[start:end] {
+		if l.op != '+' {
+			from_len++
+		}
+		if l.op != '-' {
+			to_len++
+		}
+	}
+	if from_len == 0 {
+		from_start--
+	}
+	if to_len == 0 {
+		to_start--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", from_start, from_len, to_start, to_len)
+	for _, l := range lines[start:end] {
+		fmt.Fprintf(sb, "%c%s\n", l.op, l.text)
+	}
+}
+
+// diff_lines finds the shortest edit from a to b using the longest common
+// subsequence of lines.
+func diff_lines(a, b []string) []diff_line {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diff_line
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diff_line{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diff_line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diff_line{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diff_line{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diff_line{'+', b[j]})
+	}
+	return lines
+}
+
+func split_lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
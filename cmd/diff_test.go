@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	var tests = []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "same",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "",
+		},
+		{
+			name: "change",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			to:   "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- want\n+++ got\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			from: "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			to:   "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "--- want\n+++ got\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   "x\n",
+			want: "--- want\n+++ got\n@@ -0,0 +1,1 @@\n+x\n",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			got := unified_diff("want", "got", tst.from, tst.to)
+			if diff := cmp.Diff(tst.want, got); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
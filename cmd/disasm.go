@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danwhitford/laks"
+)
+
+// run_disasm compiles a script, or loads a compiled .lakc file, and prints
+// its bytecode as assembly.
+func run_disasm(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := new_flags("disasm", "laks disasm [-e code | file.lak | file.lakc]", stderr)
+	code := flags.String("e", "", "disassemble `code` instead of a file")
+	files, err := parse_interspersed(flags, args)
+	if err != nil {
+		return flag_exit(err)
+	}
+	if len(files) > 1 {
+		flags.Usage()
+		return exit_usage
+	}
+
+	s, _, err := read_source(*code, files, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exit_usage
+	}
+	p, err := load_program(s)
+	if err != nil {
+		report_error(stderr, err, s.name, s.src, use_colour(stderr))
+		return exit_compile
+	}
+
+	listing, err := laks.DisassembleProgram(p)
+	fmt.Fprint(stdout, listing)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exit_compile
+	}
+	return 0
+}
+
+// load_program loads a compiled program, or compiles source.
+func load_program(s source) (*laks.Program, error) {
+	if laks.IsCompiledProgram(s.src) {
+		return laks.UnmarshalProgram(s.src)
+	}
+	return laks.BuildProgram(s.src, laks.WithModules(os.DirFS(s.dir)), laks.WithSourceName(s.name))
+}
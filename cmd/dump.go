@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/danwhitford/laks"
+)
+
+// run_tokens prints the tokens of a script, one to a line with where each
+// starts.
+func run_tokens(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s, as_json, status := read_dump_source("tokens", args, stdin, stderr)
+	if status >= 0 {
+		return status
+	}
+	tokens, err := laks.TokeniseFile(s.name, s.src)
+	if as_json {
+		write_json(stdout, json_tokens{json_version, to_json_tokens(tokens), to_json_diagnostics(err)})
+		return exit_code(err)
+	}
+	if err != nil {
+		report_error(stderr, err, s.name, s.src, use_colour(stderr))
+		return exit_compile
+	}
+	for _, t := range tokens {
+		pos := fmt.Sprintf("%d:%d", t.Start.Line, t.Start.Col)
+		fmt.Fprintf(stdout, "%-8s %-10v %q\n", pos, t.T, t.Lexeme)
+	}
+	return 0
+}
+
+// run_ast prints the statements parsed from a script as an indented tree.
+func run_ast(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s, as_json, status := read_dump_source("ast", args, stdin, stderr)
+	if status >= 0 {
+		return status
+	}
+	tokens, err := laks.TokeniseFile(s.name, s.src)
+	var stmts []laks.Statement
+	if err == nil {
+		stmts, err = laks.Parse(tokens)
+	}
+	if as_json {
+		write_json(stdout, json_ast{json_version, to_json_statements(stmts), to_json_diagnostics(err)})
+		return exit_code(err)
+	}
+	if err != nil {
+		report_error(stderr, err, s.name, s.src, use_colour(stderr))
+		return exit_compile
+	}
+	for _, stmt := range stmts {
+		dump_node(stdout, reflect.ValueOf(stmt), "")
+	}
+	return 0
+}
+
+// read_dump_source reads the script for a command that shows one stage of
+// the pipeline, and whether to show it as JSON. The status is -1 unless the
+// command should stop with it.
+func read_dump_source(name string, args []string, stdin io.Reader, stderr io.Writer) (source, bool, int) {
+	flags := new_flags(name, fmt.Sprintf("laks %s [--json] [-e code | file.lak]", name), stderr)
+	code := flags.String("e", "", "use `code` instead of a file")
+	as_json := flags.Bool("json", false, "print JSON for tools to read")
+	files, err := parse_interspersed(flags, args)
+	if err != nil {
+		return source{}, false, flag_exit(err)
+	}
+	if len(files) > 1 {
+		flags.Usage()
+		return source{}, false, exit_usage
+	}
+	s, _, err := read_source(*code, files, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return source{}, false, exit_usage
+	}
+	return s, *as_json, -1
+}
+
+var (
+	statement_type  = reflect.TypeFor[laks.Statement]()
+	expression_type = reflect.TypeFor[laks.Expression]()
+)
+
+// dump_node writes a node of the syntax tree, with where it starts, and
+// then its fields indented below it.
+func dump_node(w io.Writer, v reflect.Value, indent string) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		fmt.Fprintln(w, "nil")
+		return
+	}
+	span := v.FieldByName("Span").Interface().(laks.Span)
+	fmt.Fprintf(w, "%s %d:%d\n", v.Type().Name(), span.Start.Line, span.Start.Col)
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		f := v.Field(i)
+		fmt.Fprintf(w, "%s  %s:", indent, field.Name)
+		switch {
+		case is_node_type(f.Type()):
+			fmt.Fprint(w, " ")
+			dump_node(w, f, indent+"  ")
+		case f.Kind() == reflect.Slice && is_node_type(f.Type().Elem()):
+			fmt.Fprintln(w)
+			for j := range f.Len() {
+				fmt.Fprintf(w, "%s    - ", indent)
+				dump_node(w, f.Index(j), indent+"      ")
+			}
+		case field.Name == "Value":
+			fmt.Fprintf(w, " %s\n", laks.Repr(f.Interface()))
+		default:
+			fmt.Fprintf(w, " %v\n", f.Interface())
+		}
+	}
+}
+
+func is_node_type(t reflect.Type) bool {
+	return t == statement_type || t == expression_type
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danwhitford/laks"
+)
+
+// run_fmt lays out scripts in the standard style. It prints the result
+// unless -w is given to rewrite the files, or -l to list the files whose
+// layout would change.
+func run_fmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := new_flags("fmt", "laks fmt [-w | -l] [file.lak...]", stderr)
+	write := flags.Bool("w", false, "rewrite files instead of printing them")
+	list := flags.Bool("l", false, "list the files that are not formatted")
+	files, err := parse_interspersed(flags, args)
+	if err != nil {
+		return flag_exit(err)
+	}
+	if len(files) == 0 && (*write || *list) {
+		fmt.Fprintln(stderr, "-w and -l need files")
+		return exit_usage
+	}
+
+	if len(files) == 0 {
+		s, _, err := read_source("", nil, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exit_usage
+		}
+		formatted, err := laks.Format(s.src, laks.WithSourceName(s.name))
+		if err != nil {
+			report_error(stderr, err, s.name, s.src, use_colour(stderr))
+			return exit_compile
+		}
+		stdout.Write(formatted)
+		return 0
+	}
+
+	status := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			status = exit_usage
+			continue
+		}
+		formatted, err := laks.Format(src, laks.WithSourceName(file))
+		if err != nil {
+			report_error(stderr, err, file, src, use_colour(stderr))
+			status = exit_compile
+			continue
+		}
+
+		switch {
+		case *list:
+			if !bytes.Equal(src, formatted) {
+				fmt.Fprintln(stdout, file)
+			}
+		case *write:
+			if bytes.Equal(src, formatted) {
+				continue
+			}
+			if err := os.WriteFile(file, formatted, 0644); err != nil {
+				fmt.Fprintf(stderr, "error: %v\n", err)
+				status = exit_failure
+			}
+		default:
+			stdout.Write(formatted)
+		}
+	}
+	return status
+}
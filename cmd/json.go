@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/danwhitford/laks"
+)
+
+// json_version is the version of the JSON that --json prints. It goes up
+// whenever the output changes in a way that could break a program reading
+// it; adding fields does not count.
+const json_version = 1
+
+type json_pos struct {
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+	Offset int `json:"offset"`
+}
+
+// json_span is a span of source. Lines and columns count from 1 and the
+// offset is in bytes from 0. The end is just past the last character.
+type json_span struct {
+	File  string   `json:"file,omitempty"`
+	Start json_pos `json:"start"`
+	End   json_pos `json:"end"`
+}
+
+type json_token struct {
+	Type   string    `json:"type"`
+	Lexeme string    `json:"lexeme"`
+	Span   json_span `json:"span"`
+}
+
+type json_label struct {
+	Span json_span `json:"span"`
+	Text string    `json:"text"`
+}
+
+// json_diagnostic is a laks.Diagnostic. Span is missing for problems that
+// are not about any part of the source.
+type json_diagnostic struct {
+	Severity string       `json:"severity"`
+	Message  string       `json:"message"`
+	Span     *json_span   `json:"span,omitempty"`
+	Label    string       `json:"label,omitempty"`
+	Related  []json_label `json:"related,omitempty"`
+	Hint     string       `json:"hint,omitempty"`
+}
+
+type json_tokens struct {
+	Version     int               `json:"version"`
+	Tokens      []json_token      `json:"tokens"`
+	Diagnostics []json_diagnostic `json:"diagnostics"`
+}
+
+type json_ast struct {
+	Version     int               `json:"version"`
+	Statements  []json_node       `json:"statements"`
+	Diagnostics []json_diagnostic `json:"diagnostics"`
+}
+
+type json_check struct {
+	Version     int               `json:"version"`
+	Diagnostics []json_diagnostic `json:"diagnostics"`
+}
+
+// json_node is a node of the syntax tree. It always has "kind", the name
+// of its Go type such as "BinaryExpression", and "span", and then its
+// fields with snake_case names.
+type json_node map[string]any
+
+func write_json(w io.Writer, v any) {
+	json.NewEncoder(w).Encode(v)
+}
+
+func to_json_span(s laks.Span) json_span {
+	return json_span{
+		File:  s.Start.File,
+		Start: json_pos{s.Start.Line, s.Start.Col, s.Start.Offset},
+		End:   json_pos{s.End.Line, s.End.Col, s.End.Offset},
+	}
+}
+
+func to_json_tokens(tokens []laks.Token) []json_token {
+	out := make([]json_token, len(tokens))
+	for i, t := range tokens {
+		out[i] = json_token{t.T.String(), t.Lexeme, to_json_span(t.Span)}
+	}
+	return out
+}
+
+// to_json_diagnostics turns err into diagnostics, which is an empty list
+// rather than null when there is no error.
+func to_json_diagnostics(err error) []json_diagnostic {
+	out := []json_diagnostic{}
+	for _, d := range laks.Diagnostics(err) {
+		jd := json_diagnostic{
+			Severity: string(d.Severity),
+			Message:  d.Message,
+			Label:    d.Label,
+			Hint:     d.Hint,
+		}
+		if d.Span.Start.IsValid() {
+			span := to_json_span(d.Span)
+			jd.Span = &span
+		}
+		for _, l := range d.Related {
+			jd.Related = append(jd.Related, json_label{to_json_span(l.Span), l.Text})
+		}
+		out = append(out, jd)
+	}
+	return out
+}
+
+func to_json_statements(stmts []laks.Statement) []json_node {
+	out := make([]json_node, len(stmts))
+	for i, stmt := range stmts {
+		out[i] = to_json_node(reflect.ValueOf(stmt))
+	}
+	return out
+}
+
+// to_json_node turns a node of the syntax tree into JSON the same way
+// dump_node shows it, with nil for a missing optional node.
+func to_json_node(v reflect.Value) json_node {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	node := json_node{
+		"kind": v.Type().Name(),
+		"span": to_json_span(v.FieldByName("Span").Interface().(laks.Span)),
+	}
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		f := v.Field(i)
+		key := snake_case(field.Name)
+		switch {
+		case is_node_type(f.Type()):
+			node[key] = to_json_node(f)
+		case f.Kind() == reflect.Slice && is_node_type(f.Type().Elem()):
+			children := make([]json_node, f.Len())
+			for j := range f.Len() {
+				children[j] = to_json_node(f.Index(j))
+			}
+			node[key] = children
+		case field.Name == "Value":
+			node["type"], node[key] = json_value(f.Interface())
+		case f.Type().Implements(reflect.TypeFor[fmt.Stringer]()):
+			node[key] = f.Interface().(fmt.Stringer).String()
+		default:
+			node[key] = f.Interface()
+		}
+	}
+	return node
+}
+
+// json_value gives the type of a literal's value and the value as JSON.
+func json_value(v laks.Value) (string, any) {
+	switch v := v.(type) {
+	case laks.IntValue:
+		return "int", int64(v)
+	case laks.FloatValue:
+		return "float", float64(v)
+	case laks.StringValue:
+		return "string", string(v)
+	case laks.TrueValue:
+		return "bool", true
+	case laks.FalseValue:
+		return "bool", false
+	case laks.NilValue, nil:
+		return "nil", nil
+	default:
+		return "unknown", laks.Repr(v)
+	}
+}
+
+func snake_case(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
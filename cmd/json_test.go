@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTokensJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run_laks([]string{"tokens", "--json", "-e", "print 1;"}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("wanted success but got %d %q", code, stderr.String())
+	}
+	var got json_tokens
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want := json_tokens{
+		Version: json_version,
+		Tokens: []json_token{
+			{"T_KEYWORD", "print", json_span{"-e", json_pos{1, 1, 0}, json_pos{1, 6, 5}}},
+			{"T_INT", "1", json_span{"-e", json_pos{1, 7, 6}, json_pos{1, 8, 7}}},
+			{"T_SEMI", ";", json_span{"-e", json_pos{1, 8, 7}, json_pos{1, 9, 8}}},
+		},
+		Diagnostics: []json_diagnostic{},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestASTJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run_laks([]string{"ast", "--json", "-e", `print -1 + x;`}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("wanted success but got %d %q", code, stderr.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	span := func(start, end float64) map[string]any {
+		return map[string]any{
+			"file":  "-e",
+			"start": map[string]any{"line": 1.0, "col": start + 1, "offset": start},
+			"end":   map[string]any{"line": 1.0, "col": end + 1, "offset": end},
+		}
+	}
+	want := map[string]any{
+		"version": 1.0,
+		"statements": []any{
+			map[string]any{
+				"kind": "PrintStatment",
+				"span": span(0, 12),
+				"exprs": []any{
+					map[string]any{
+						"kind": "BinaryExpression",
+						"span": span(6, 12),
+						"op":   "BO_ADD",
+						"left": map[string]any{
+							"kind": "UnaryExpression",
+							"span": span(6, 8),
+							"op":   "UO_NEGATE",
+							"expr": map[string]any{
+								"kind":  "LiteralExpression",
+								"span":  span(7, 8),
+								"type":  "int",
+								"value": 1.0,
+							},
+						},
+						"right": map[string]any{
+							"kind": "VariableExpression",
+							"span": span(11, 12),
+							"name": "x",
+						},
+					},
+				},
+			},
+		},
+		"diagnostics": []any{},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run_laks([]string{"check", "--json", "-e", "prnt 1;\nprint (2;"}, nil, &stdout, &stderr)
+	if code != exit_compile {
+		t.Errorf("wanted exit code %d but got %d", exit_compile, code)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("wanted nothing on stderr but got %q", stderr.String())
+	}
+	var got json_check
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want := json_check{
+		Version: json_version,
+		Diagnostics: []json_diagnostic{
+			{
+				Severity: "error",
+				Message:  "error parsing statement. do not recognise keyword 'prnt'",
+				Span:     &json_span{"-e", json_pos{1, 1, 0}, json_pos{1, 5, 4}},
+				Label:    "unknown keyword",
+				Hint:     "did you mean 'print'?",
+			},
+			{
+				Severity: "error",
+				Message:  "error parsing statement. error consuming. wanted 'T_RPAREN' but got 'T_SEMI'",
+				Span:     &json_span{"-e", json_pos{2, 9, 16}, json_pos{2, 10, 17}},
+				Label:    "expected ')'",
+				Related:  []json_label{{json_span{"-e", json_pos{2, 7, 14}, json_pos{2, 8, 15}}, "to close this '('"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
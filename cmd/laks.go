@@ -1,30 +1,218 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/danwhitford/laks"
 )
 
+const usage = `laks runs laks scripts.
+
+Usage:
+  laks [file.lak [args...]]   run a script, or start the REPL on a terminal
+  laks -e code [args...]      run code given on the command line
+  laks - [args...]            run a script read from stdin
+  laks <command> [arguments]
+
+Commands:
+  run      run a script
+  check    report problems in scripts without running them
+  build    compile a script to a .lakc file
+  test     run the tests in *_test.lak files
+  fmt      lay scripts out in the standard style
+  tokens   show the tokens of a script
+  ast      show the syntax tree of a script
+  disasm   show the bytecode a script compiles to
+  repl     start the REPL
+
+Run 'laks <command> --help' for more about a command.
+`
+
 func main() {
-	var f *os.File
+	os.Exit(run_laks(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run_laks runs the command in args, returning the exit code for the
+// process. Without a command the first argument is the script to run.
+func run_laks(args []string, stdin *os.File, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		if is_terminal(stdin) {
+			return run_repl(stdin, stdout)
+		}
+		return run_script(nil, stdin, stdout, stderr)
+	}
+
+	switch args[0] {
+	case "run":
+		return run_script(args[1:], stdin, stdout, stderr)
+	case "check":
+		return run_check(args[1:], stdin, stdout, stderr)
+	case "build":
+		return run_build(args[1:], stdout, stderr)
+	case "test":
+		return run_tests(args[1:], stdout, stderr)
+	case "fmt":
+		return run_fmt(args[1:], stdin, stdout, stderr)
+	case "tokens":
+		return run_tokens(args[1:], stdin, stdout, stderr)
+	case "ast":
+		return run_ast(args[1:], stdin, stdout, stderr)
+	case "disasm":
+		return run_disasm(args[1:], stdin, stdout, stderr)
+	case "repl":
+		return run_repl(stdin, stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
 
-	if len(os.Args) > 1 {
-		fname := os.Args[1]
-		var err error
-		f, err = os.Open(fname)
+	name := args[0]
+	if name == "-e" || name == "-" {
+		return run_script(args, stdin, stdout, stderr)
+	}
+	if strings.HasPrefix(name, "-") {
+		fmt.Fprintf(stderr, "unknown flag '%s'\n\n%s", name, usage)
+		return exit_usage
+	}
+	if _, err := os.Stat(name); err != nil && !strings.ContainsAny(name, "./") {
+		fmt.Fprintf(stderr, "unknown command '%s'. Run 'laks --help' for usage.\n", name)
+		return exit_usage
+	}
+	return run_script(args, stdin, stdout, stderr)
+}
+
+// run_script runs a script, passing it the arguments after its name. A
+// script that calls exit(n) exits with n.
+func run_script(args []string, stdin *os.File, stdout, stderr io.Writer) int {
+	flags := new_flags("run", "laks run [-e code | file.lak] [args...]", stderr)
+	code := flags.String("e", "", "run `code` instead of a file")
+	if err := flags.Parse(args); err != nil {
+		return flag_exit(err)
+	}
+	s, script_args, err := read_source(*code, flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exit_usage
+	}
+
+	opts := []laks.Option{
+		laks.WithInput(stdin),
+		laks.WithFileAccess(""),
+		laks.WithArgs(script_args...),
+	}
+	if laks.IsCompiledProgram(s.src) {
+		p, err := laks.UnmarshalProgram(s.src)
 		if err != nil {
-			panic(err)
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exit_compile
 		}
-	} else {
-		f = os.Stdin
+		err = laks.RunProgram(p, stdout, opts...)
+		return report_exit(stderr, err, "", nil)
 	}
 
-	b, err := io.ReadAll(f)
+	opts = append(opts, laks.WithModules(os.DirFS(s.dir)), laks.WithSourceName(s.name))
+	err = laks.RunBytes(s.src, stdout, opts...)
+	return report_exit(stderr, err, s.name, s.src)
+}
+
+// run_check tokenises, parses and compiles scripts without running them,
+// reporting every problem found. With --json the problems are printed as
+// JSON diagnostics instead.
+func run_check(args []string, stdin *os.File, stdout, stderr io.Writer) int {
+	flags := new_flags("check", "laks check [--json] [-e code | file.lak...]", stderr)
+	code := flags.String("e", "", "check `code` instead of files")
+	as_json := flags.Bool("json", false, "print diagnostics as JSON for tools to read")
+	files, err := parse_interspersed(flags, args)
 	if err != nil {
-		panic(err)
+		return flag_exit(err)
+	}
+
+	var sources []source
+	if *code != "" || len(files) == 0 {
+		s, _, err := read_source(*code, nil, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exit_usage
+		}
+		sources = append(sources, s)
+	}
+	for _, file := range files {
+		s, _, err := read_source("", []string{file}, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exit_usage
+		}
+		sources = append(sources, s)
 	}
 
-	laks.RunBytes(b, os.Stdout)
+	status := 0
+	diags := []json_diagnostic{}
+	for _, s := range sources {
+		_, err := laks.BuildProgram(s.src, laks.WithModules(os.DirFS(s.dir)), laks.WithSourceName(s.name), laks.WithMaxErrors(0))
+		if err == nil {
+			continue
+		}
+		status = exit_compile
+		if *as_json {
+			diags = append(diags, to_json_diagnostics(err)...)
+		} else {
+			report_error(stderr, err, s.name, s.src, use_colour(stderr))
+		}
+	}
+	if *as_json {
+		write_json(stdout, json_check{json_version, diags})
+	}
+	return status
+}
+
+// source is a script to work on, from a file, -e or stdin.
+type source struct {
+	name string
+	dir  string // where its imports are found
+	src  []byte
+}
+
+// read_source reads the code given with -e, or else the file named first
+// in args, or else stdin if there is no file or it is "-". It returns the
+// arguments that are left.
+func read_source(code string, args []string, stdin io.Reader) (source, []string, error) {
+	if code != "" {
+		return source{"-e", ".", []byte(code)}, args, nil
+	}
+	if len(args) == 0 || args[0] == "-" {
+		b, err := io.ReadAll(stdin)
+		if len(args) > 0 {
+			args = args[1:]
+		}
+		return source{"<stdin>", ".", b}, args, err
+	}
+	b, err := os.ReadFile(args[0])
+	return source{args[0], filepath.Dir(args[0]), b}, args[1:], err
+}
+
+// new_flags makes the flags for a command, with its usage shown by --help
+// and after mistakes.
+func new_flags(name, synopsis string, w io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(w)
+	flags.Usage = func() {
+		fmt.Fprintf(w, "usage: %s\n", synopsis)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// flag_exit is the exit code after parsing flags failed, which is success
+// if it was only --help.
+func flag_exit(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return exit_usage
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunScriptExitCodes(t *testing.T) {
+	var tests = []struct {
+		name   string
+		src    string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{"ok", `print 1;`, 0, "1\n", ""},
+		{"syntax error", `print (1;`, exit_compile, "", "error: "},
+		{"trailing operator", `print 1 =`, exit_compile, "", "error: "},
+		{"compile error", `import "missing.lak";`, exit_compile, "", "error: error importing"},
+		{"runtime error", "print 1;\nprint 1 / 0;", exit_failure, "1\n", "error: division by zero"},
+		{"exit", `print 1; exit(4);`, 4, "1\n", ""},
+		{"exit zero", `exit(0); print 1;`, 0, "", ""},
+		{"exit 256", `exit(256);`, exit_failure, "", "error: error calling exit. exit code must be from 0 to 255 but got 256"},
+		{"exit -1", `exit(-1);`, exit_failure, "", "error: error calling exit. exit code must be from 0 to 255 but got -1"},
+		{"exit 255", `exit(255);`, 255, "", ""},
+	}
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	defer stdin.Close()
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			file := filepath.Join(tt.TempDir(), "main.lak")
+			if err := os.WriteFile(file, []byte(tst.src), 0644); err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			var stdout, stderr bytes.Buffer
+			code := run_script([]string{file}, stdin, &stdout, &stderr)
+			if code != tst.code {
+				tt.Errorf("wanted exit code %d but got %d", tst.code, code)
+			}
+			if stdout.String() != tst.stdout {
+				tt.Errorf("wanted output %q but got %q", tst.stdout, stdout.String())
+			}
+			if !strings.HasPrefix(stderr.String(), tst.stderr) || (tst.stderr == "") != (stderr.Len() == 0) {
+				tt.Errorf("wanted errors starting %q but got %q", tst.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunScriptUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run_script([]string{filepath.Join(t.TempDir(), "nope.lak")}, nil, &stdout, &stderr)
+	if code != exit_usage {
+		t.Errorf("wanted exit code %d but got %d", exit_usage, code)
+	}
+	if !strings.HasPrefix(stderr.String(), "error: open ") {
+		t.Errorf("wanted an error about the file but got %q", stderr.String())
+	}
+}
+
+func TestCommands(t *testing.T) {
+	var tests = []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"-e", `print args;`, "a", "b"}, 0, "[\"a\", \"b\"]\n", ""},
+		{[]string{"run", "-e", `print len(args); exit(2);`, "x"}, 2, "1\n", ""},
+		{[]string{"check", "-e", `print 1;`}, 0, "", ""},
+		{[]string{"check", "-e", `print (1; print 2 +;`}, exit_compile, "", "error: "},
+		{[]string{"tokens", "-e", `print 1;`}, 0, "1:1      T_KEYWORD  \"print\"\n1:7      T_INT      \"1\"\n1:8      T_SEMI     \";\"\n", ""},
+		{[]string{"ast", "-e", `print -x;`}, 0, "PrintStatment 1:1\n  Exprs:\n    - UnaryExpression 1:7\n        Op: UO_NEGATE\n        Expr: VariableExpression 1:8\n          Name: x\n", ""},
+		{[]string{"disasm", "-e", `1;`}, 0, "; -e:1:1\n0000  OP_CONST       0 VAL_INT 1\n0003  OP_POP\n", ""},
+		{[]string{"fmt", "-e", `1;`}, exit_usage, "", "flag provided but not defined: -e"},
+		{[]string{"run", "--help"}, 0, "", "usage: laks run"},
+		{[]string{"test", "--update"}, exit_usage, "", "error: --update only works with --golden"},
+		{[]string{"test", "--frob"}, exit_usage, "", "flag provided but not defined: -frob"},
+		{[]string{"--help"}, 0, "laks runs laks scripts.", ""},
+		{[]string{"frob"}, exit_usage, "", "unknown command 'frob'"},
+		{[]string{"--frob"}, exit_usage, "", "unknown flag '--frob'"},
+	}
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	defer stdin.Close()
+	for _, tst := range tests {
+		t.Run(strings.Join(tst.args, " "), func(tt *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run_laks(tst.args, stdin, &stdout, &stderr)
+			if code != tst.code {
+				tt.Errorf("wanted exit code %d but got %d", tst.code, code)
+			}
+			if !strings.HasPrefix(stdout.String(), tst.stdout) || (tst.stdout == "") != (stdout.Len() == 0) {
+				tt.Errorf("wanted output starting %q but got %q", tst.stdout, stdout.String())
+			}
+			if !strings.HasPrefix(stderr.String(), tst.stderr) || (tst.stderr == "") != (stderr.Len() == 0) {
+				tt.Errorf("wanted errors starting %q but got %q", tst.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestFmtCommand(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.lak")
+	if err := os.WriteFile(file, []byte("print 1+2;test \"t\"{assert true;}"), 0644); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run_laks([]string{"fmt", "-l", file}, nil, &stdout, &stderr); code != 0 || stdout.String() != file+"\n" {
+		t.Errorf("wanted %s listed but got %d %q %q", file, code, stdout.String(), stderr.String())
+	}
+	if code := run_laks([]string{"fmt", "-w", file}, nil, &stdout, &stderr); code != 0 {
+		t.Errorf("wanted fmt -w to work but got %d %q", code, stderr.String())
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want := "print 1 + 2;\ntest \"t\" {\n    assert true;\n}\n"
+	if string(got) != want {
+		t.Errorf("wanted %q but got %q", want, string(got))
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+var err_interrupted = errors.New("interrupted")
+
+// line_editor reads lines from a terminal with emacs style editing keys,
+// arrow keys and history. When the terminal cannot be put into raw mode it
+// falls back to reading plain lines.
+type line_editor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	fd      int
+	history []string
+}
+
+func new_line_editor(in *bufio.Reader, fd int, out io.Writer) *line_editor {
+	return &line_editor{in: in, out: out, fd: fd}
+}
+
+// add_history remembers a line, returning false if it was skipped for being
+// empty or the same as the last one.
+func (e *line_editor) add_history(line string) bool {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return false
+	}
+	e.history = append(e.history, line)
+	return true
+}
+
+// read_line shows prompt and returns the line typed, without its newline.
+// It returns io.EOF for ctrl-D on an empty line and err_interrupted for
+// ctrl-C.
+func (e *line_editor) read_line(prompt string) (string, error) {
+	state, err := make_raw(e.fd)
+	if err != nil {
+		return e.read_plain_line(prompt)
+	}
+	defer restore(e.fd, state)
+
+	l := line_state{prompt: prompt, history_pos: len(e.history)}
+	l.redraw(e.out)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(l.buf), nil
+		case 3: // ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", err_interrupted
+		case 4: // ctrl-D
+			if len(l.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			l.delete()
+		case 127, 8: // backspace
+			l.backspace()
+		case 1: // ctrl-A
+			l.pos = 0
+		case 5: // ctrl-E
+			l.pos = len(l.buf)
+		case 2: // ctrl-B
+			l.left()
+		case 6: // ctrl-F
+			l.right()
+		case 11: // ctrl-K
+			l.buf = l.buf[:l.pos]
+		case 21: // ctrl-U
+			l.buf = l.buf[l.pos:]
+			l.pos = 0
+		case 23: // ctrl-W
+			l.delete_word()
+		case 16: // ctrl-P
+			l.history_move(e.history, -1)
+		case 14: // ctrl-N
+			l.history_move(e.history, 1)
+		case 27: // escape sequence
+			e.read_escape(&l)
+		default:
+			if unicode.IsPrint(r) {
+				l.insert(r)
+			}
+		}
+		l.redraw(e.out)
+	}
+}
+
+func (e *line_editor) read_escape(l *line_state) {
+	b, err := e.in.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return
+	}
+	b, err = e.in.ReadByte()
+	if err != nil {
+		return
+	}
+	switch b {
+	case 'A':
+		l.history_move(e.history, -1)
+	case 'B':
+		l.history_move(e.history, 1)
+	case 'C':
+		l.right()
+	case 'D':
+		l.left()
+	case 'H':
+		l.pos = 0
+	case 'F':
+		l.pos = len(l.buf)
+	case '3':
+		if next, _ := e.in.ReadByte(); next == '~' {
+			l.delete()
+		}
+	}
+}
+
+func (e *line_editor) read_plain_line(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// line_state is the line being edited.
+type line_state struct {
+	prompt      string
+	buf         []rune
+	pos         int
+	history_pos int
+	pending     []rune // what was typed before moving through history
+}
+
+func (l *line_state) redraw(w io.Writer) {
+	fmt.Fprintf(w, "\r%s%s\x1b[K", l.prompt, string(l.buf))
+	if back := len(l.buf) - l.pos; back > 0 {
+		fmt.Fprintf(w, "\x1b[%dD", back)
+	}
+}
+
+func (l *line_state) insert(r rune) {
+	l.buf = append(l.buf[:l.pos], append([]rune{r}, l.buf[l.pos:]...)...)
+	l.pos++
+}
+
+func (l *line_state) backspace() {
+	if l.pos > 0 {
+		l.buf = append(l.buf[:l.pos-1], l.buf[l.pos:]...)
+		l.pos--
+	}
+}
+
+func (l *line_state) delete() {
+	if l.pos < len(l.buf) {
+		l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+	}
+}
+
+func (l *line_state) delete_word() {
+	start := l.pos
+	for start > 0 && l.buf[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && l.buf[start-1] != ' ' {
+		start--
+	}
+	l.buf = append(l.buf[:start], l.buf[l.pos:]...)
+	l.pos = start
+}
+
+func (l *line_state) left() {
+	if l.pos > 0 {
+		l.pos--
+	}
+}
+
+func (l *line_state) right() {
+	if l.pos < len(l.buf) {
+		l.pos++
+	}
+}
+
+// history_move steps through history, remembering the line being typed so
+// stepping back past the newest entry restores it.
+func (l *line_state) history_move(history []string, step int) {
+	next := l.history_pos + step
+	if next < 0 || next > len(history) {
+		return
+	}
+	if l.history_pos == len(history) {
+		l.pending = l.buf
+	}
+	l.history_pos = next
+	if next == len(history) {
+		l.buf = l.pending
+	} else {
+		l.buf = []rune(history[next])
+	}
+	l.pos = len(l.buf)
+}
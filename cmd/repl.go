@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/danwhitford/laks"
+)
+
+const repl_help = `Enter statements to run them. Input continues over several lines until
+braces are closed and it ends with ';' or '}'. Expression values are printed.
+
+Commands:
+  :tokens <code>    show the tokens for code
+  :ast <code>       show the statements parsed from code
+  :bytecode <code>  show the bytecode compiled from code
+  :help             show this help
+  :quit             leave the REPL (or press ctrl-D)
+`
+
+func is_terminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// run_repl reads, evaluates and prints until the input ends. Everything
+// runs on one interpreter so later input sees what earlier input did.
+func run_repl(in *os.File, out io.Writer) int {
+	// The editor and the script's input() share one buffer, so neither
+	// loses lines the other has read ahead.
+	r := bufio.NewReader(in)
+	editor := new_line_editor(r, int(in.Fd()), out)
+	history := history_path()
+	load_history(editor, history)
+
+	interp := laks.NewInterpreter(out,
+		laks.WithModules(os.DirFS(".")),
+		laks.WithInput(r),
+		laks.WithFileAccess(""),
+	)
+
+	fmt.Fprintln(out, "laks REPL. Type :help for help.")
+	var entry []string
+	for {
+		prompt := "> "
+		if len(entry) > 0 {
+			prompt = ". "
+		}
+		line, err := editor.read_line(prompt)
+		if errors.Is(err, err_interrupted) {
+			entry = nil
+			continue
+		}
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return exit_failure
+		}
+
+		entry = append(entry, line)
+		src := strings.Join(entry, "\n")
+		if strings.TrimSpace(src) == "" {
+			entry = nil
+			continue
+		}
+		if !strings.HasPrefix(strings.TrimSpace(src), ":") && incomplete(src) {
+			continue
+		}
+		entry = nil
+
+		one_line := strings.Join(strings.Fields(src), " ")
+		if editor.add_history(one_line) {
+			save_history(history, one_line)
+		}
+
+		if cmd, ok := strings.CutPrefix(strings.TrimSpace(src), ":"); ok {
+			if run_repl_command(cmd, out) {
+				return 0
+			}
+			continue
+		}
+
+		v, err := interp.Eval([]byte(src))
+		var exit *laks.ExitError
+		if errors.As(err, &exit) {
+			return exit.Code
+		}
+		if err != nil {
+			report_error(out, err, "", []byte(src), use_colour(out))
+			continue
+		}
+		if _, is_nil := v.(laks.NilValue); v != nil && !is_nil {
+			fmt.Fprintln(out, laks.Repr(v))
+		}
+	}
+}
+
+// incomplete reports whether src needs more lines: it is inside a string
+// or brackets, or does not yet end a statement.
+func incomplete(src string) bool {
+	tokens, err := laks.Tokenise([]byte(src))
+	if errors.Is(err, laks.ErrUnterminatedString) {
+		return true
+	}
+	if err != nil || len(tokens) == 0 {
+		return false
+	}
+
+	depth := 0
+	for _, t := range tokens {
+		switch t.T {
+		case laks.T_LBRACE, laks.T_LPAREN:
+			depth++
+		case laks.T_RBRACE, laks.T_RPAREN:
+			depth--
+		}
+	}
+	last := tokens[len(tokens)-1].T
+	return depth > 0 || (last != laks.T_SEMI && last != laks.T_RBRACE)
+}
+
+// run_repl_command runs a ':' command, returning true if the REPL should
+// stop.
+func run_repl_command(cmd string, out io.Writer) bool {
+	name, code, _ := strings.Cut(cmd, " ")
+	switch name {
+	case "quit", "q":
+		return true
+	case "help", "h":
+		fmt.Fprint(out, repl_help)
+	case "tokens", "ast", "bytecode":
+		if strings.TrimSpace(code) == "" {
+			fmt.Fprintf(out, "usage: :%s <code>\n", name)
+			return false
+		}
+		err := show_stage(name, []byte(code), out)
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	default:
+		fmt.Fprintf(out, "unknown command ':%s'. Type :help for help.\n", name)
+	}
+	return false
+}
+
+// show_stage prints the output of one stage of the pipeline for src.
+func show_stage(stage string, src []byte, out io.Writer) error {
+	tokens, err := laks.Tokenise(src)
+	if err != nil {
+		return err
+	}
+	if stage == "tokens" {
+		for _, t := range tokens {
+			fmt.Fprintf(out, "%-10v %q\n", t.T, t.Lexeme)
+		}
+		return nil
+	}
+
+	stmts, err := laks.Parse(tokens)
+	if err != nil {
+		return err
+	}
+	if stage == "ast" {
+		for _, stmt := range stmts {
+			dump_node(out, reflect.ValueOf(stmt), "")
+		}
+		return nil
+	}
+
+	bytecode, err := laks.Compile(stmts)
+	if err != nil {
+		return err
+	}
+	listing, err := laks.Disassemble(bytecode)
+	fmt.Fprint(out, listing)
+	return err
+}
+
+// history_path is where REPL history is kept, $LAKS_HISTORY or
+// ~/.laks_history.
+func history_path() string {
+	if p := os.Getenv("LAKS_HISTORY"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".laks_history")
+}
+
+const max_history = 1000
+
+func load_history(editor *line_editor, path string) {
+	if path == "" {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) > max_history {
+		lines = lines[len(lines)-max_history:]
+	}
+	for _, line := range lines {
+		editor.add_history(line)
+	}
+}
+
+func save_history(path, line string) {
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
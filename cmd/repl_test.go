@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want bool
+	}{
+		{`print 1;`, false},
+		{`print 1`, true},
+		{`print 1 +`, true},
+		{`print "unclosed`, true},
+		{`print upper(`, true},
+		{"test \"x\" {\n  assert true;", true},
+		{"test \"x\" {\n  assert true;\n}", false},
+		{`# just a comment`, false},
+		{`print ~;`, false},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			if got := incomplete(tst.in); got != tst.want {
+				tt.Errorf("wanted %v but got %v", tst.want, got)
+			}
+		})
+	}
+}
+
+func TestLineStateHistory(t *testing.T) {
+	history := []string{"one", "two"}
+	l := line_state{history_pos: len(history)}
+	l.insert('x')
+
+	l.history_move(history, -1)
+	if string(l.buf) != "two" {
+		t.Errorf("wanted 'two' but got %q", string(l.buf))
+	}
+	l.history_move(history, -1)
+	l.history_move(history, -1)
+	if string(l.buf) != "one" {
+		t.Errorf("wanted 'one' but got %q", string(l.buf))
+	}
+	l.history_move(history, 1)
+	l.history_move(history, 1)
+	if string(l.buf) != "x" {
+		t.Errorf("wanted the pending line 'x' back but got %q", string(l.buf))
+	}
+}
+
+func TestReplSharesInput(t *testing.T) {
+	t.Setenv("LAKS_HISTORY", filepath.Join(t.TempDir(), "history"))
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w.WriteString("print input();\nhello\nprint read_line();\nworld\n")
+	w.Close()
+
+	var out bytes.Buffer
+	if code := run_repl(r, &out); code != 0 {
+		t.Fatalf("wanted exit code 0 but got %d", code)
+	}
+	for _, want := range []string{"hello\n", "world\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("wanted output containing %q but got %q", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type term_state struct {
+	termios syscall.Termios
+}
+
+// make_raw puts the terminal into raw mode so keys arrive one at a time
+// without being echoed, returning the state to restore afterwards.
+func make_raw(fd int) (*term_state, error) {
+	var old syscall.Termios
+	err := ioctl(fd, syscall.TCGETS, &old)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	err = ioctl(fd, syscall.TCSETS, &raw)
+	if err != nil {
+		return nil, err
+	}
+	return &term_state{old}, nil
+}
+
+func restore(fd int, state *term_state) error {
+	return ioctl(fd, syscall.TCSETS, &state.termios)
+}
+
+func ioctl(fd int, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
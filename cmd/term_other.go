@@ -0,0 +1,17 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+type term_state struct{}
+
+// make_raw is only supported on Linux. Elsewhere the line editor falls back
+// to reading whole lines.
+func make_raw(fd int) (*term_state, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+func restore(fd int, state *term_state) error {
+	return nil
+}
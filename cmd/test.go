@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danwhitford/laks"
+)
+
+// run_tests runs the tests found under the paths in args, returning the
+// exit code for the process. By default that means the test blocks in every
+// *_test.lak file. With --golden it instead runs every .lak file and checks
+// its output against the expectations in its comments, which --update
+// rewrites to match.
+func run_tests(args []string, stdout, stderr io.Writer) int {
+	flags := new_flags("test", "laks test [--golden [--update]] [path...]", stderr)
+	golden := flags.Bool("golden", false, "check .lak files against the expected output in their '# ' comments")
+	update := flags.Bool("update", false, "with --golden, rewrite the expected output from the actual output")
+	paths, err := parse_interspersed(flags, args)
+	if err != nil {
+		return flag_exit(err)
+	}
+	if *update && !*golden {
+		fmt.Fprintln(stderr, "error: --update only works with --golden")
+		return exit_usage
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	suffix := "_test.lak"
+	if *golden {
+		suffix = ".lak"
+	}
+	files, err := find_files(paths, suffix)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exit_failure
+	}
+
+	passed, failed := 0, 0
+	for _, file := range files {
+		var p, f int
+		if *golden {
+			p, f = run_golden_file(file, *update, stdout)
+		} else {
+			p, f = run_test_file(file, stdout)
+		}
+		passed += p
+		failed += f
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(stdout, "FAIL: %d failed, %d passed\n", failed, passed)
+		return exit_failure
+	}
+	fmt.Fprintf(stdout, "PASS: %d passed\n", passed)
+	return 0
+}
+
+// parse_interspersed parses flags that may come before, after or between
+// the positional arguments, which it returns.
+func parse_interspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func find_files(paths []string, suffix string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(p, suffix) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// run_test_file reports on each test in a file. A file that cannot be run at
+// all counts as a single failure.
+func run_test_file(file string, w io.Writer) (passed, failed int) {
+	fmt.Fprintf(w, "=== %s\n", file)
+	b, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(w, "--- FAIL: %v\n", err)
+		return 0, 1
+	}
+
+	results, err := laks.RunTests(b,
+		laks.WithModules(os.DirFS(filepath.Dir(file))),
+		laks.WithSourceName(file),
+		laks.WithInput(os.Stdin),
+		laks.WithFileAccess(""),
+	)
+	if err != nil {
+		fmt.Fprintf(w, "--- FAIL: %v\n", err)
+		return 0, 1
+	}
+
+	for _, r := range results {
+		if r.Err == nil {
+			fmt.Fprintf(w, "--- PASS: %s\n", r.Name)
+			passed++
+			continue
+		}
+		fmt.Fprintf(w, "--- FAIL: %s\n", r.Name)
+		fmt.Fprintf(w, "    %v\n", r.Err)
+		for _, line := range strings.Split(strings.TrimSuffix(r.Output, "\n"), "\n") {
+			if line != "" {
+				fmt.Fprintf(w, "    | %s\n", line)
+			}
+		}
+		failed++
+	}
+	return passed, failed
+}
+
+// run_golden_file runs a program and compares its output with what its
+// comments expect, updating them instead when asked to.
+func run_golden_file(file string, update bool, w io.Writer) (passed, failed int) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(w, "--- FAIL: %s\n    %v\n", file, err)
+		return 0, 1
+	}
+
+	var out strings.Builder
+	err = laks.RunBytes(b, &out,
+		laks.WithModules(os.DirFS(filepath.Dir(file))),
+		laks.WithSourceName(file),
+		laks.WithFileAccess(""),
+	)
+	if err != nil {
+		fmt.Fprintf(w, "--- FAIL: %s\n    %v\n", file, err)
+		return 0, 1
+	}
+
+	expected := laks.ExpectedOutput(b)
+	diff := unified_diff(file+" (expected)", file+" (actual)", expected, out.String())
+	if diff == "" {
+		fmt.Fprintf(w, "--- PASS: %s\n", file)
+		return 1, 0
+	}
+
+	if update {
+		err = os.WriteFile(file, laks.UpdateExpectedOutput(b, out.String()), 0644)
+		if err != nil {
+			fmt.Fprintf(w, "--- FAIL: %s\n    %v\n", file, err)
+			return 0, 1
+		}
+		fmt.Fprintf(w, "--- UPDATED: %s\n", file)
+		return 1, 0
+	}
+
+	fmt.Fprintf(w, "--- FAIL: %s\n%s", file, diff)
+	return 0, 1
+}
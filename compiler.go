@@ -3,6 +3,7 @@ package laks
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 )
 
 //go:generate stringer -type=OpCode
@@ -16,45 +17,104 @@ const (
 	OP_DIV
 	OP_MINUS
 	OP_EQ
+	OP_GET_GLOBAL
+	OP_CALL
+	OP_NEGATE
+	OP_GET_ATTR
+	OP_PRINTN
+	OP_WRITE
+	OP_POP
+	OP_ASSERT
+	OP_ASSERT_CMP
+	OP_SET_ATTR
+	OP_CONST
+	OP_RETURN
 )
 
-func compileLiteralExpression(expr LiteralExpression) ([]byte, error) {
-	var buf []byte
+// compiler turns statements into bytecode. Ints, floats and strings go in a
+// constant pool, unless the code has to run on its own without a Program,
+// when every literal is written into the code.
+type compiler struct {
+	inline    bool
+	constants []Value
+	index     map[Value]int
+}
+
+func (c *compiler) compileLiteralExpression(expr LiteralExpression) ([]byte, error) {
+	switch expr.Value.(type) {
+	case IntValue, FloatValue, StringValue:
+		if !c.inline {
+			i, err := c.constant(expr.Value)
+			if err != nil {
+				return nil, compile_errorf(expr.Span, "%v", err)
+			}
+			return binary.LittleEndian.AppendUint16([]byte{byte(OP_CONST)}, uint16(i)), nil
+		}
+	}
+	buf, err := appendValue([]byte{byte(OP_PUSH)}, expr.Value)
+	if err != nil {
+		return buf, compile_errorf(expr.Span, "do not know how to compile litexpr '%v'. %v", expr.Value, err)
+	}
+	return buf, nil
+}
+
+// constant returns the index of v in the constant pool, adding it if it is
+// not there yet.
+func (c *compiler) constant(v Value) (int, error) {
+	if i, ok := c.index[v]; ok {
+		return i, nil
+	}
+	if len(c.constants) > math.MaxUint16 {
+		return 0, fmt.Errorf("too many constants. the limit is %d", math.MaxUint16+1)
+	}
+	if c.index == nil {
+		c.index = make(map[Value]int)
+	}
+	c.index[v] = len(c.constants)
+	c.constants = append(c.constants, v)
+	return len(c.constants) - 1, nil
+}
+
+// appendValue writes a constant as its ValueType followed by its payload,
+// which is how OP_PUSH takes its operand.
+func appendValue(buf []byte, v Value) ([]byte, error) {
 	var err error
-	switch v := expr.Value.(type) {
+	switch v := v.(type) {
 	case IntValue:
 		buf = append(buf, byte(VAL_INT))
-		buf = append(buf, byte(OP_PUSH))
 		buf, err = binary.Append(buf, binary.LittleEndian, v)
 		if err != nil {
-			err = fmt.Errorf("error appending '%#v'. %v", expr.Value, err)
+			err = fmt.Errorf("error appending '%#v'. %v", v, err)
+		}
+	case FloatValue:
+		buf = append(buf, byte(VAL_FLOAT))
+		buf, err = binary.Append(buf, binary.LittleEndian, v)
+		if err != nil {
+			err = fmt.Errorf("error appending '%#v'. %v", v, err)
 		}
 	case TrueValue:
-		buf = append(buf, byte(OP_PUSH))
 		buf = append(buf, byte(VAL_TRUE))
 	case FalseValue:
-		buf = append(buf, byte(OP_PUSH))
 		buf = append(buf, byte(VAL_FALSE))
+	case NilValue:
+		buf = append(buf, byte(VAL_NIL))
 	case StringValue:
-		buf = append(buf, byte(OP_PUSH))
 		buf = append(buf, byte(VAL_STRING))
-		bb := []byte(v)
-		buf = append(buf, bb...)
-		buf = append(buf, 0)
+		buf = appendString(buf, string(v))
 	default:
-		return buf, fmt.Errorf("do not know how to compile litexpr '%v'", expr)
+		return buf, fmt.Errorf("'%T' is not a constant", v)
 	}
 	return buf, err
 }
 
-func compileBinaryExpression(bexpr BinaryExpression) ([]byte, error) {
+func (c *compiler) compileBinaryExpression(bexpr BinaryExpression) ([]byte, error) {
 	var buf []byte
-	left, err := compileStatement(bexpr.Left)
+	left, err := c.compileExpression(bexpr.Left)
 	if err != nil {
 		return buf, err
 	}
 	buf = append(buf, left...)
-	right, err := compileStatement(bexpr.Right)
+	right, err := c.compileExpression(bexpr.Right)
 	if err != nil {
 		return buf, err
 	}
@@ -72,42 +132,260 @@ func compileBinaryExpression(bexpr BinaryExpression) ([]byte, error) {
 	case BO_EQ:
 		buf = append(buf, byte(OP_EQ))
 	default:
-		return buf, fmt.Errorf("unknown operator '%v'", bexpr.Op)
+		return buf, compile_errorf(bexpr.Span, "unknown operator '%v'", bexpr.Op)
+	}
+
+	return buf, nil
+}
+
+func (c *compiler) compileUnaryExpression(uexpr UnaryExpression) ([]byte, error) {
+	buf, err := c.compileExpression(uexpr.Expr)
+	if err != nil {
+		return buf, err
+	}
+
+	switch uexpr.Op {
+	case UO_NEGATE:
+		buf = append(buf, byte(OP_NEGATE))
+	default:
+		return buf, compile_errorf(uexpr.Span, "unknown operator '%v'", uexpr.Op)
 	}
 
 	return buf, nil
 }
 
-func compilePrint(p PrintStatment) ([]byte, error) {
-	b, err := compileStatement(p.Expr)
+func (c *compiler) compileGetAttrExpression(get GetAttrExpression) ([]byte, error) {
+	buf, err := c.compileExpression(get.Object)
+	if err != nil {
+		return buf, err
+	}
+	buf = append(buf, byte(OP_GET_ATTR))
+	return appendString(buf, get.Name), nil
+}
+
+// compileSetAttr leaves the object and then the value on the stack for
+// OP_SET_ATTR.
+func (c *compiler) compileSetAttr(set SetAttrStatement) ([]byte, error) {
+	buf, err := c.compileExpression(set.Object)
+	if err != nil {
+		return buf, err
+	}
+	vb, err := c.compileExpression(set.Value)
+	if err != nil {
+		return buf, err
+	}
+	buf = append(buf, vb...)
+	buf = append(buf, byte(OP_SET_ATTR))
+	return appendString(buf, set.Name), nil
+}
+
+func (c *compiler) compileVariableExpression(v VariableExpression) ([]byte, error) {
+	buf := []byte{byte(OP_GET_GLOBAL)}
+	return appendString(buf, v.Name), nil
+}
+
+func (c *compiler) compileCallExpression(call CallExpression) ([]byte, error) {
+	if len(call.Args) > 255 {
+		return nil, compile_errorf(call.Span, "too many arguments in call. got %d but the limit is 255", len(call.Args))
+	}
+	buf, err := c.compileExpression(call.Callee)
+	if err != nil {
+		return buf, err
+	}
+	for _, arg := range call.Args {
+		b, err := c.compileExpression(arg)
+		if err != nil {
+			return buf, wrap_error(err, arg.span(), "error compiling argument '%s'", expression_source(arg))
+		}
+		buf = append(buf, b...)
+	}
+	buf = append(buf, byte(OP_CALL), byte(len(call.Args)))
+	return buf, nil
+}
+
+// appendString writes s as a null-terminated string.
+func appendString(buf []byte, s string) []byte {
+	buf = append(buf, []byte(s)...)
+	return append(buf, 0)
+}
+
+func (c *compiler) compilePrint(p PrintStatment) ([]byte, error) {
+	b, err := c.compileOutputs(p.Exprs)
 	if err != nil {
-		return b, fmt.Errorf("error compiling expression for printing '%v'. '%v'", p.Expr, err)
+		return b, err
+	}
+	if len(p.Exprs) == 1 {
+		return append(b, byte(OP_PRINT)), nil
+	}
+	return append(b, byte(OP_PRINTN), byte(len(p.Exprs))), nil
+}
+
+func (c *compiler) compileWrite(w WriteStatement) ([]byte, error) {
+	b, err := c.compileOutputs(w.Exprs)
+	if err != nil {
+		return b, err
+	}
+	return append(b, byte(OP_WRITE), byte(len(w.Exprs))), nil
+}
+
+// compileAssert pushes the failure message before the asserted value. When
+// asserting a comparison both operands are left on the stack so a failure
+// can report them.
+func (c *compiler) compileAssert(a AssertStatement) ([]byte, error) {
+	msg := a.Message
+	if msg == nil {
+		msg = LiteralExpression{StringValue(expression_source(a.Expr)), a.Expr.span()}
+	}
+	b, err := c.compileExpression(msg)
+	if err != nil {
+		return b, wrap_error(err, msg.span(), "error compiling assert message")
+	}
+
+	if cmp, ok := a.Expr.(BinaryExpression); ok && cmp.Op == BO_EQ {
+		for _, operand := range []Expression{cmp.Left, cmp.Right} {
+			ob, err := c.compileExpression(operand)
+			if err != nil {
+				return b, err
+			}
+			b = append(b, ob...)
+		}
+		return append(b, byte(OP_ASSERT_CMP), byte(cmp.Op)), nil
+	}
+
+	eb, err := c.compileExpression(a.Expr)
+	if err != nil {
+		return b, err
+	}
+	b = append(b, eb...)
+	return append(b, byte(OP_ASSERT)), nil
+}
+
+func (c *compiler) compileOutputs(exprs []Expression) ([]byte, error) {
+	if len(exprs) > 255 {
+		return nil, compile_errorf(exprs[0].span().join(exprs[len(exprs)-1].span()), "too many values to output. got %d but the limit is 255", len(exprs))
+	}
+	var b []byte
+	for _, expr := range exprs {
+		eb, err := c.compileExpression(expr)
+		if err != nil {
+			return b, wrap_error(err, expr.span(), "error compiling expression for printing '%s'", expression_source(expr))
+		}
+		b = append(b, eb...)
 	}
-	b = append(b, byte(OP_PRINT))
 	return b, nil
 }
 
-func compileStatement(stmt Statement) ([]byte, error) {
+func (c *compiler) compileStatement(stmt Statement) ([]byte, error) {
 	switch v := stmt.(type) {
 	case PrintStatment:
-		return compilePrint(v)
+		return c.compilePrint(v)
+	case WriteStatement:
+		return c.compileWrite(v)
+	case ExpressionStatement:
+		b, err := c.compileExpression(v.Expr)
+		if err != nil {
+			return b, err
+		}
+		return append(b, byte(OP_POP)), nil
+	case AssertStatement:
+		return c.compileAssert(v)
+	case SetAttrStatement:
+		return c.compileSetAttr(v)
+	case TestStatement:
+		return nil, compile_errorf(v.Span, "tests cannot be nested")
+	case ImportStatement:
+		return nil, compile_errorf(v.Span, "unresolved import '%s'", v.Path)
+	default:
+		return nil, fmt.Errorf("unknown statement type '%T'", v)
+	}
+}
+
+func (c *compiler) compileExpression(expr Expression) ([]byte, error) {
+	switch v := expr.(type) {
 	case BinaryExpression:
-		return compileBinaryExpression(v)
+		return c.compileBinaryExpression(v)
 	case LiteralExpression:
-		return compileLiteralExpression(v)
+		return c.compileLiteralExpression(v)
+	case UnaryExpression:
+		return c.compileUnaryExpression(v)
+	case GetAttrExpression:
+		return c.compileGetAttrExpression(v)
+	case VariableExpression:
+		return c.compileVariableExpression(v)
+	case CallExpression:
+		return c.compileCallExpression(v)
 	default:
-		return nil, fmt.Errorf("unknown statement type '%T'", v)
+		return nil, fmt.Errorf("unknown expression type '%T'", v)
 	}
 }
 
+// Compile compiles stmts to bytecode that runs on its own with Run, so
+// constants are written into the code rather than a pool.
 func Compile(stmts []Statement) ([]byte, error) {
-	var bytecode []byte
+	c := compiler{inline: true}
+	p, err := c.program(stmts, nil)
+	return p.Code, err
+}
+
+// CompileProgram compiles stmts to a program with a constant pool, a
+// function table holding its test blocks, and debug info recording which
+// statement each part of the code came from.
+func CompileProgram(stmts []Statement) (*Program, error) {
+	var c compiler
+	return c.program(stmts, nil)
+}
+
+// program compiles the statements outside of test blocks as the main
+// program, followed by result if it is not nil, whose value is left on the
+// stack. Each test block then follows as a function, with OP_RETURN ending
+// the one before it.
+func (c *compiler) program(stmts []Statement, result Expression) (*Program, error) {
+	p := &Program{Debug: &DebugInfo{}}
+	emit := func(b []byte, at Pos) {
+		if len(b) > 0 {
+			p.Debug.Lines.add(len(p.Code), at)
+		}
+		p.Code = append(p.Code, b...)
+	}
+	body := func(stmts []Statement) error {
+		for _, stmt := range stmts {
+			b, err := c.compileStatement(stmt)
+			if err != nil {
+				return wrap_error(err, stmt.span(), "error compiling statement")
+			}
+			emit(b, stmt.span().Start)
+		}
+		return nil
+	}
+
+	var tests []TestStatement
+	var main []Statement
 	for _, stmt := range stmts {
-		b, err := compileStatement(stmt)
+		if test, ok := stmt.(TestStatement); ok {
+			tests = append(tests, test)
+		} else {
+			main = append(main, stmt)
+		}
+	}
+	if err := body(main); err != nil {
+		return p, err
+	}
+	if result != nil {
+		b, err := c.compileExpression(result)
 		if err != nil {
-			return bytecode, fmt.Errorf("error compiling statement '%v'. '%v'", stmt, err)
+			return p, err
+		}
+		emit(b, result.span().Start)
+	}
+
+	p.Functions = []FunctionInfo{{Name: main_frame}}
+	for _, test := range tests {
+		p.Code = append(p.Code, byte(OP_RETURN))
+		p.Functions = append(p.Functions, FunctionInfo{Name: test.Name, Offset: len(p.Code)})
+		if err := body(test.Body); err != nil {
+			return p, err
 		}
-		bytecode = append(bytecode, b...)
 	}
-	return bytecode, nil
+	p.Constants = c.constants
+	return p, nil
 }
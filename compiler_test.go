@@ -15,21 +15,24 @@ func TestCompile(t *testing.T) {
 		{
 			name: "literal",
 			in: []Statement{
-				LiteralExpression{IntValue(int64(14))},
+				ExpressionStatement{Expr: LiteralExpression{Value: IntValue(int64(14))}},
 			},
 			want: []byte{
 				byte(OP_PUSH),
 				byte(VAL_INT),
 				14, 0, 0, 0, 0, 0, 0, 0, // 14
+				byte(OP_POP),
 			},
 		},
 		{
 			name: "expradd",
 			in: []Statement{
-				BinaryExpression{
-					Op:    BO_ADD,
-					Left:  LiteralExpression{IntValue(int64(7))},
-					Right: LiteralExpression{IntValue(int64(9))},
+				ExpressionStatement{
+					Expr: BinaryExpression{
+						Op:    BO_ADD,
+						Left:  LiteralExpression{Value: IntValue(int64(7))},
+						Right: LiteralExpression{Value: IntValue(int64(9))},
+					},
 				},
 			},
 			want: []byte{
@@ -40,16 +43,19 @@ func TestCompile(t *testing.T) {
 				byte(VAL_INT),
 				9, 0, 0, 0, 0, 0, 0, 0, // 9
 				byte(OP_ADD),
+				byte(OP_POP),
 			},
 		},
 		{
 			name: "print expression",
 			in: []Statement{
 				PrintStatment{
-					Expr: BinaryExpression{
-						Op:    BO_MULT,
-						Left:  LiteralExpression{IntValue(int64(7))},
-						Right: LiteralExpression{IntValue(int64(9))},
+					Exprs: []Expression{
+						BinaryExpression{
+							Op:    BO_MULT,
+							Left:  LiteralExpression{Value: IntValue(int64(7))},
+							Right: LiteralExpression{Value: IntValue(int64(9))},
+						},
 					},
 				},
 			},
@@ -68,7 +74,7 @@ func TestCompile(t *testing.T) {
 			name: "simple true",
 			in: []Statement{
 				PrintStatment{
-					Expr: LiteralExpression{TrueValue(true)},
+					Exprs: []Expression{LiteralExpression{Value: TrueValue(true)}},
 				},
 			},
 			want: []byte{
@@ -77,6 +83,75 @@ func TestCompile(t *testing.T) {
 				byte(OP_PRINT),
 			},
 		},
+		{
+			name: "negative float",
+			in: []Statement{
+				ExpressionStatement{
+					Expr: UnaryExpression{
+						Op:   UO_NEGATE,
+						Expr: LiteralExpression{Value: FloatValue(1.5)},
+					},
+				},
+			},
+			want: []byte{
+				byte(OP_PUSH),
+				byte(VAL_FLOAT),
+				0, 0, 0, 0, 0, 0, 0xf8, 0x3f, // 1.5
+				byte(OP_NEGATE),
+				byte(OP_POP),
+			},
+		},
+		{
+			name: "call",
+			in: []Statement{
+				PrintStatment{
+					Exprs: []Expression{
+						CallExpression{
+							Callee: VariableExpression{Name: "len"},
+							Args: []Expression{
+								LiteralExpression{Value: StringValue("ab")},
+							},
+						},
+					},
+				},
+			},
+			want: []byte{
+				byte(OP_GET_GLOBAL),
+				'l', 'e', 'n', 0,
+				byte(OP_PUSH),
+				byte(VAL_STRING),
+				'a', 'b', 0,
+				byte(OP_CALL),
+				1,
+				byte(OP_PRINT),
+			},
+		},
+		{
+			name: "print many",
+			in: []Statement{
+				PrintStatment{
+					Exprs: []Expression{
+						LiteralExpression{Value: TrueValue(true)},
+						LiteralExpression{Value: FalseValue(false)},
+					},
+				},
+				WriteStatement{
+					Exprs: []Expression{LiteralExpression{Value: TrueValue(true)}},
+				},
+			},
+			want: []byte{
+				byte(OP_PUSH),
+				byte(VAL_TRUE),
+				byte(OP_PUSH),
+				byte(VAL_FALSE),
+				byte(OP_PRINTN),
+				2,
+				byte(OP_PUSH),
+				byte(VAL_TRUE),
+				byte(OP_WRITE),
+				1,
+			},
+		},
 	}
 
 	for _, tst := range tests {
@@ -91,3 +166,25 @@ func TestCompile(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileErrorPosition(t *testing.T) {
+	_, err := CompileBytes([]byte("print 1;\nimport \"a.lak\";"), WithSourceName("main.lak"))
+	want := "main.lak:2:1: error importing 'a.lak'. no module loader configured"
+	if err == nil || err.Error() != want {
+		t.Errorf("wanted error %q but got %v", want, err)
+	}
+
+	tokens, err := TokeniseFile("main.lak", []byte(`print 1; import "a.lak";`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	stmts, err := Parse(tokens)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	_, err = Compile(stmts)
+	want = "main.lak:1:10: error compiling statement. unresolved import 'a.lak'"
+	if err == nil || err.Error() != want {
+		t.Errorf("wanted error %q but got %v", want, err)
+	}
+}
@@ -0,0 +1,199 @@
+package laks
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity is how serious a diagnostic is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Label points at part of the source with a note about it.
+type Label struct {
+	Span Span
+	Text string
+}
+
+// Diagnostic is a problem to show to the person who wrote a script. Span
+// is where the problem is and Label, if set, is shown under it. Related
+// points at other places that help explain the problem.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Span     Span
+	Label    string
+	Related  []Label
+	Hint     string
+}
+
+// Diagnostics turns any error from laks into diagnostics. A SyntaxErrors
+// gives one for each error, and errors without a position give one that
+// cannot be shown against the source.
+func Diagnostics(err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+	var list SyntaxErrors
+	if errors.As(err, &list) {
+		diags := make([]Diagnostic, len(list))
+		for i, e := range list {
+			diags[i] = Diagnostics(e)[0]
+		}
+		return diags
+	}
+
+	d := Diagnostic{Severity: SeverityError, Message: err.Error()}
+	var se *SyntaxError
+	var ce *CompileError
+	var re *RuntimeError
+	switch {
+	case errors.As(err, &se):
+		d.Message, d.Span, d.Label, d.Related, d.Hint = se.Msg, se.Span, se.Label, se.Related, se.Hint
+	case errors.As(err, &ce):
+		d.Message, d.Span, d.Hint = ce.Msg, ce.Span, ce.Hint
+	case errors.As(err, &re):
+		d.Message, d.Span, d.Hint = re.Msg, Span{re.Pos, re.Pos}, re.Hint
+	}
+	return []Diagnostic{d}
+}
+
+// RenderDiagnostic shows d the way compilers like rustc do: the message,
+// where it is, the lines of src it points at with the spans underlined, and
+// any hint. src must be the source of the file d.Span is in. With colour
+// the output has ANSI escapes for a terminal.
+func RenderDiagnostic(d Diagnostic, src []byte, colour bool) string {
+	p := painter{colour}
+	var sb strings.Builder
+
+	severity := p.paint(severity_colour(d.Severity), string(d.Severity))
+	fmt.Fprintf(&sb, "%s%s\n", severity, p.paint(bold, ": "+d.Message))
+
+	start := d.Span.Start
+	if !start.IsValid() {
+		render_hint(&sb, p, "", d.Hint)
+		return sb.String()
+	}
+
+	labels := []source_label{{Label{d.Span, d.Label}, true}}
+	var elsewhere []Label
+	for _, l := range d.Related {
+		if l.Span.Start.File == start.File && l.Span.Start.IsValid() {
+			labels = append(labels, source_label{l, false})
+		} else {
+			elsewhere = append(elsewhere, l)
+		}
+	}
+	slices.SortStableFunc(labels, func(a, b source_label) int {
+		return a.Span.Start.Offset - b.Span.Start.Offset
+	})
+
+	lines := strings.Split(string(src), "\n")
+	width := len(fmt.Sprint(labels[len(labels)-1].Span.Start.Line))
+	gutter := strings.Repeat(" ", width)
+	bar := p.paint(blue, "|")
+
+	fmt.Fprintf(&sb, "%s%s %v\n", gutter, p.paint(blue, "-->"), start)
+	fmt.Fprintf(&sb, "%s %s\n", gutter, bar)
+	last_line := 0
+	for _, l := range labels {
+		line := l.Span.Start.Line
+		if line > len(lines) {
+			continue
+		}
+		if line != last_line {
+			if last_line != 0 && line > last_line+1 {
+				fmt.Fprintf(&sb, "%s\n", p.paint(blue, "..."+gutter[min(len(gutter), 2):]))
+			}
+			text := expand_tabs(lines[line-1])
+			fmt.Fprintf(&sb, "%s %s %s\n", p.paint(blue, fmt.Sprintf("%*d", width, line)), bar, text)
+			last_line = line
+		}
+		fmt.Fprintf(&sb, "%s %s %s\n", gutter, bar, underline(p, lines[line-1], l))
+	}
+	for _, l := range elsewhere {
+		fmt.Fprintf(&sb, "%s %s %s: %s\n", gutter, p.paint(blue, "="), p.paint(bold, "note"), l.Text)
+		if l.Span.Start.IsValid() {
+			fmt.Fprintf(&sb, "%s   at %v\n", gutter, l.Span.Start)
+		}
+	}
+	render_hint(&sb, p, gutter, d.Hint)
+	return sb.String()
+}
+
+// source_label is a label being drawn under its line of source.
+type source_label struct {
+	Label
+	primary bool
+}
+
+// underline draws the markers under the part of line a label covers,
+// followed by the label's text. Spans over several lines are underlined to
+// the end of their first line.
+func underline(p painter, line string, l source_label) string {
+	col := l.Span.Start.Col - 1
+	col = min(max(col, 0), len(line))
+	end := len(line)
+	if l.Span.End.Line == l.Span.Start.Line {
+		end = min(max(l.Span.End.Col-1, col), len(line))
+	}
+
+	indent := utf8.RuneCountInString(expand_tabs(line[:col]))
+	width := max(utf8.RuneCountInString(expand_tabs(line[col:end])), 1)
+
+	marker, c := "-", blue
+	if l.primary {
+		marker, c = "^", red
+	}
+	marks := strings.Repeat(marker, width)
+	if l.Text != "" {
+		marks += " " + l.Text
+	}
+	return strings.Repeat(" ", indent) + p.paint(c, marks)
+}
+
+func render_hint(sb *strings.Builder, p painter, gutter, hint string) {
+	if hint == "" {
+		return
+	}
+	fmt.Fprintf(sb, "%s %s %s: %s\n", gutter, p.paint(blue, "="), p.paint(bold, "help"), hint)
+}
+
+// expand_tabs replaces tabs with spaces so that underlines line up.
+func expand_tabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
+
+const (
+	bold   = "\x1b[1m"
+	red    = "\x1b[1;31m"
+	blue   = "\x1b[1;34m"
+	yellow = "\x1b[1;33m"
+	reset  = "\x1b[0m"
+)
+
+func severity_colour(s Severity) string {
+	if s == SeverityWarning {
+		return yellow
+	}
+	return red
+}
+
+// painter colours text when colour is wanted.
+type painter struct {
+	colour bool
+}
+
+func (p painter) paint(c, s string) string {
+	if !p.colour {
+		return s
+	}
+	return c + s + reset
+}
@@ -0,0 +1,113 @@
+package laks
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderDiagnostic(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "unclosed bracket",
+			in:   "print 1;\nprint (2 + 3;",
+			want: `error: error parsing statement. error consuming. wanted 'T_RPAREN' but got 'T_SEMI'
+ --> main.lak:2:13
+  |
+2 | print (2 + 3;
+  |       - to close this '('
+  |             ^ expected ')'
+`,
+		},
+		{
+			name: "unclosed test",
+			in:   "test \"x\" {\n\tprint 1;\n\n\tprint 2;",
+			want: `error: error parsing test 'x'. EOF
+ --> main.lak:4:10
+  |
+1 | test "x" {
+  |          - to close this '{'
+...
+4 |     print 2;
+  |             ^ expected '}'
+`,
+		},
+		{
+			name: "runtime error",
+			in:   "print 1;\nprint \"a\" + 1;",
+			want: `error: cannot apply '+' to string and int
+ --> main.lak:2:1
+  |
+2 | print "a" + 1;
+  | ^
+`,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			err := RunBytes([]byte(tst.in), io.Discard, WithSourceName("main.lak"))
+			diags := Diagnostics(err)
+			if len(diags) != 1 {
+				tt.Fatalf("wanted one diagnostic but got %v", diags)
+			}
+			got := RenderDiagnostic(diags[0], []byte(tst.in), false)
+			if diff := cmp.Diff(tst.want, got); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderDiagnosticHint(t *testing.T) {
+	d := Diagnostic{
+		Severity: SeverityError,
+		Message:  "undefined name 'x'",
+		Span:     Span{Pos{"", 1, 7, 6}, Pos{"", 1, 8, 7}},
+		Hint:     "did you mean 'y'?",
+	}
+	want := `error: undefined name 'x'
+ --> 1:7
+  |
+1 | print x;
+  |       ^
+  = help: did you mean 'y'?
+`
+	if diff := cmp.Diff(want, RenderDiagnostic(d, []byte("print x;"), false)); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+
+	got := RenderDiagnostic(d, []byte("print x;"), true)
+	if !strings.Contains(got, red) || !strings.Contains(got, reset) {
+		t.Errorf("wanted colour but got %q", got)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	_, err := BuildProgram([]byte("print (1;\nprint 2;\nprint 3 +;"))
+	diags := Diagnostics(err)
+	var got []Pos
+	for _, d := range diags {
+		got = append(got, d.Span.Start)
+	}
+	want := []Pos{{"", 1, 9, 8}, {"", 3, 10, 28}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+
+	diags = Diagnostics(errors.New("no such file"))
+	want_diag := []Diagnostic{{Severity: SeverityError, Message: "no such file"}}
+	if diff := cmp.Diff(want_diag, diags); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+	if got := RenderDiagnostic(diags[0], nil, false); got != "error: no such file\n" {
+		t.Errorf("wanted just the message but got %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package laks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Disassemble renders bytecode as one instruction per line, showing its
+// offset, the opcode and its decoded operands. Bytecode that cannot be
+// decoded is reported as an error rather than guessed at.
+func Disassemble(bytecode []byte) (string, error) {
+	return disassemble(disassembler{bytecode: bytecode}, nil, nil)
+}
+
+// DisassembleProgram is Disassemble with the values of constants, a
+// '; test "name"' comment where each test block starts, and a
+// '; file:line:col' comment before the code for each statement when the
+// program has debug info.
+func DisassembleProgram(p *Program) (string, error) {
+	var lines LineTable
+	if p.Debug != nil {
+		lines = p.Debug.Lines
+	}
+	var tests []FunctionInfo
+	if len(p.Functions) > 0 {
+		tests = p.Functions[1:]
+	}
+	return disassemble(disassembler{bytecode: p.Code, constants: p.Constants}, lines, tests)
+}
+
+func disassemble(d disassembler, lines LineTable, tests []FunctionInfo) (string, error) {
+	for d.ip < len(d.bytecode) {
+		for len(tests) > 0 && tests[0].Offset <= d.ip {
+			fmt.Fprintf(&d.sb, "; test %q\n", tests[0].Name)
+			tests = tests[1:]
+		}
+		for len(lines) > 0 && lines[0].Offset <= d.ip {
+			fmt.Fprintf(&d.sb, "; %v\n", lines[0].Pos)
+			lines = lines[1:]
+		}
+		err := d.instruction()
+		if err != nil {
+			return d.sb.String(), err
+		}
+	}
+	return d.sb.String(), nil
+}
+
+type disassembler struct {
+	bytecode  []byte
+	constants []Value // nil when only the code is known
+	ip        int
+	sb        strings.Builder
+}
+
+func (d *disassembler) instruction() error {
+	offset := d.ip
+	op := OpCode(d.bytecode[d.ip])
+	d.ip++
+
+	var operands string
+	var err error
+	switch op {
+	case OP_PUSH:
+		operands, err = d.value()
+	case OP_CONST:
+		operands, err = d.constant()
+	case OP_GET_GLOBAL, OP_GET_ATTR, OP_SET_ATTR:
+		var s string
+		s, err = d.string()
+		operands = s
+	case OP_CALL, OP_PRINTN, OP_WRITE:
+		var n byte
+		n, err = d.byte()
+		operands = strconv.Itoa(int(n))
+	case OP_ASSERT_CMP:
+		var b byte
+		b, err = d.byte()
+		operands = BinaryOperator(b).String()
+	case OP_ADD, OP_MULT, OP_PRINT, OP_DIV, OP_MINUS, OP_EQ, OP_NEGATE, OP_POP, OP_ASSERT, OP_RETURN:
+	default:
+		err = fmt.Errorf("unknown opcode %d", byte(op))
+	}
+	if err != nil {
+		return fmt.Errorf("malformed bytecode at %04x. %v", offset, err)
+	}
+
+	line := fmt.Sprintf("%04x  %-14v %s", offset, op, operands)
+	d.sb.WriteString(strings.TrimRight(line, " "))
+	d.sb.WriteByte('\n')
+	return nil
+}
+
+func (d *disassembler) value() (string, error) {
+	v, n, err := decode_value(d.bytecode[d.ip:])
+	if err != nil {
+		return "", err
+	}
+	d.ip += n
+
+	return describe_value(v), nil
+}
+
+// constant reads a constant's index, showing its value too when the
+// constant pool is known.
+func (d *disassembler) constant() (string, error) {
+	b, err := d.bytes(2)
+	if err != nil {
+		return "", err
+	}
+	i := int(binary.LittleEndian.Uint16(b))
+	if d.constants == nil {
+		return strconv.Itoa(i), nil
+	}
+	if i >= len(d.constants) {
+		return "", fmt.Errorf("constant %d is not in the pool of %d", i, len(d.constants))
+	}
+	return fmt.Sprintf("%d %s", i, describe_value(d.constants[i])), nil
+}
+
+// describe_value shows a value with its type, or just the type for values
+// that are all there is of it.
+func describe_value(v Value) string {
+	t := value_type(v)
+	switch t {
+	case VAL_TRUE, VAL_FALSE, VAL_NIL:
+		return t.String()
+	default:
+		return fmt.Sprintf("%v %s", t, repr_value(v))
+	}
+}
+
+// string reads a null-terminated string operand, returning it quoted.
+func (d *disassembler) string() (string, error) {
+	end := d.ip
+	for end < len(d.bytecode) && d.bytecode[end] != 0 {
+		end++
+	}
+	if end == len(d.bytecode) {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := string(d.bytecode[d.ip:end])
+	d.ip = end + 1
+	return strconv.Quote(s), nil
+}
+
+func (d *disassembler) byte() (byte, error) {
+	b, err := d.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *disassembler) bytes(n int) ([]byte, error) {
+	if d.ip+n > len(d.bytecode) {
+		return nil, fmt.Errorf("wanted %d bytes of operand but only %d left", n, len(d.bytecode)-d.ip)
+	}
+	b := d.bytecode[d.ip : d.ip+n]
+	d.ip += n
+	return b, nil
+}
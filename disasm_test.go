@@ -0,0 +1,100 @@
+package laks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDisassemble(t *testing.T) {
+	tokens, err := Tokenise([]byte(`print len("ab") + -1.5, nil; assert math.pi == 3;`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	stmts, err := Parse(tokens)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	bytecode, err := Compile(stmts)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	got, err := Disassemble(bytecode)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want := `0000  OP_GET_GLOBAL  "len"
+0005  OP_PUSH        VAL_STRING "ab"
+000a  OP_CALL        1
+000c  OP_PUSH        VAL_FLOAT 1.5
+0016  OP_NEGATE
+0017  OP_ADD
+0018  OP_PUSH        VAL_NIL
+001a  OP_PRINTN      2
+001c  OP_PUSH        VAL_STRING "math.pi == 3"
+002b  OP_GET_GLOBAL  "math"
+0031  OP_GET_ATTR    "pi"
+0035  OP_PUSH        VAL_INT 3
+003f  OP_ASSERT_CMP  BO_EQ
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDisassembleProgram(t *testing.T) {
+	p, err := BuildProgram([]byte("print 1;\n\nprint nil; print \"a\", 1;\ntest \"t\" {\n    assert true;\n}"), WithSourceName("a.lak"))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	got, err := DisassembleProgram(p)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want := `; a.lak:1:1
+0000  OP_CONST       0 VAL_INT 1
+0003  OP_PRINT
+; a.lak:3:1
+0004  OP_PUSH        VAL_NIL
+0006  OP_PRINT
+; a.lak:3:12
+0007  OP_CONST       1 VAL_STRING "a"
+000a  OP_CONST       0 VAL_INT 1
+000d  OP_PRINTN      2
+000f  OP_RETURN
+; test "t"
+; a.lak:5:5
+0010  OP_CONST       2 VAL_STRING "true"
+0013  OP_PUSH        VAL_TRUE
+0015  OP_ASSERT
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDisassembleMalformed(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   []byte
+	}{
+		{"unknown opcode", []byte{255}},
+		{"truncated int", []byte{byte(OP_PUSH), byte(VAL_INT), 1, 2}},
+		{"missing value type", []byte{byte(OP_PUSH)}},
+		{"missing constant index", []byte{byte(OP_CONST), 0}},
+		{"unknown value type", []byte{byte(OP_PUSH), 200}},
+		{"unterminated string", []byte{byte(OP_GET_GLOBAL), 'a', 'b'}},
+		{"missing argument count", []byte{byte(OP_POP), byte(OP_CALL)}},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			_, err := Disassemble(tst.in)
+			if err == nil {
+				tt.Fatalf("wanted error")
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package laks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The errors from each stage of the pipeline have their own type, so
+// callers can use errors.As to tell a script that could not be read from
+// one that failed while running. Each has an optional Hint suggesting how
+// to fix the problem.
+
+// SyntaxError is an error tokenising or parsing source. Label, if set, is
+// a short note about the span for diagnostics to show, and Related points
+// at other places in the source that explain the error.
+type SyntaxError struct {
+	Span    Span
+	Msg     string
+	Hint    string
+	Label   string
+	Related []Label
+	Err     error // the error this was made from, if any
+}
+
+// CompileError is an error turning a parsed program into bytecode,
+// including resolving its imports.
+type CompileError struct {
+	Span Span
+	Msg  string
+	Hint string
+	Err  error // the error this was made from, if any
+}
+
+func (e *SyntaxError) Error() string {
+	return error_message(e.Span.Start, e.Msg, e.Hint)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// SyntaxErrors is every syntax error found in a program, in the order they
+// were found.
+type SyntaxErrors []*SyntaxError
+
+func (e SyntaxErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e SyntaxErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e *CompileError) Error() string {
+	return error_message(e.Span.Start, e.Msg, e.Hint)
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// error_message puts the position, when there is one, in front of the
+// message, and the hint after it.
+func error_message(pos Pos, msg, hint string) string {
+	if hint != "" {
+		msg += ". " + hint
+	}
+	if !pos.IsValid() {
+		return msg
+	}
+	return fmt.Sprintf("%v: %s", pos, msg)
+}
+
+func syntax_errorf(span Span, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Span: span, Msg: fmt.Sprintf(format, args...)}
+}
+
+func compile_errorf(span Span, format string, args ...any) error {
+	return &CompileError{Span: span, Msg: fmt.Sprintf(format, args...)}
+}
+
+// wrap_error adds context to err, keeping its type and position. Any
+// other error becomes a CompileError at span.
+func wrap_error(err error, span Span, format string, args ...any) error {
+	context := fmt.Sprintf(format, args...) + ". "
+	var list SyntaxErrors
+	if errors.As(err, &list) {
+		wrapped := make(SyntaxErrors, len(list))
+		for i, se := range list {
+			w := *se
+			w.Msg = context + se.Msg
+			wrapped[i] = &w
+		}
+		return wrapped
+	}
+	var se *SyntaxError
+	if errors.As(err, &se) {
+		wrapped := *se
+		wrapped.Msg = context + se.Msg
+		return &wrapped
+	}
+	var ce *CompileError
+	if errors.As(err, &ce) {
+		wrapped := *ce
+		wrapped.Msg = context + ce.Msg
+		return &wrapped
+	}
+	return &CompileError{Span: span, Msg: context + err.Error(), Err: err}
+}
+
+// RuntimeError is an error raised while a program is running. Pos is where
+// the failing code came from, when the program has a line table. Operands
+// are the types of the values the instruction failed on, if they were the
+// problem.
+type RuntimeError struct {
+	Msg      string
+	Hint     string
+	Pos      Pos
+	Op       OpCode
+	Operands []string
+	Trace    []Frame // the active calls, innermost first
+	Err      error
+}
+
+// Frame is a call that was active when a runtime error happened. Pos is
+// where that call had got to, and is not valid for natives.
+type Frame struct {
+	Name string
+	Pos  Pos
+}
+
+func (e *RuntimeError) Error() string {
+	return error_message(e.Pos, e.Msg, e.Hint)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// StackTrace lists the calls that were active, one per line, innermost
+// first.
+func (e *RuntimeError) StackTrace() string {
+	var sb strings.Builder
+	for _, f := range e.Trace {
+		switch {
+		case f.Pos.IsValid():
+			fmt.Fprintf(&sb, "\tat %s (%v)\n", f.Name, f.Pos)
+		case f.Name == main_frame:
+			fmt.Fprintf(&sb, "\tat %s\n", f.Name)
+		default:
+			fmt.Fprintf(&sb, "\tat %s (native)\n", f.Name)
+		}
+	}
+	return sb.String()
+}
+
+// ExitError is returned when a script stops itself by calling exit. It is
+// not a failure of the script, so it is not a *RuntimeError; Code is the
+// status the script asked for.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// main_frame names the code outside of any function.
+const main_frame = "<main>"
+
+// operand_error is an error caused by the values an instruction was given.
+type operand_error struct {
+	operands []Value
+	msg      string
+}
+
+func (e *operand_error) Error() string {
+	return e.msg
+}
+
+func operand_errorf(operands []Value, format string, args ...any) error {
+	return &operand_error{operands, fmt.Sprintf(format, args...)}
+}
+
+// hinted_error is an error with a hint for whoever wrote the script, which
+// becomes the Hint of the error it ends up in.
+type hinted_error struct {
+	err  error
+	hint string
+}
+
+func (e *hinted_error) Error() string {
+	return e.err.Error()
+}
+
+func (e *hinted_error) Unwrap() error {
+	return e.err
+}
+
+// with_hint adds hint to err, leaving it alone if there is no hint.
+func with_hint(err error, hint string) error {
+	if hint == "" {
+		return err
+	}
+	return &hinted_error{err, hint}
+}
@@ -0,0 +1,79 @@
+package laks
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestErrorTypes(t *testing.T) {
+	modules := fstest.MapFS{
+		"bad.lak": {Data: []byte(`print (;`)},
+	}
+	var tests = []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"tokenising", `print $;`, "syntax"},
+		{"unterminated string", `print "a;`, "syntax"},
+		{"parsing", `print 1`, "syntax"},
+		{"parsing a module", `import "bad.lak";`, "syntax"},
+		{"missing module", `import "missing.lak";`, "compile"},
+		{"too many arguments", `print len(1` + strings.Repeat(", 1", 255) + `);`, "compile"},
+		{"undefined name", `print nope;`, "runtime"},
+		{"type error", `print 1 + "a";`, "runtime"},
+		{"native error", `print int("x");`, "runtime"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			err := RunBytes([]byte(tst.in), io.Discard, WithModules(modules))
+			var se *SyntaxError
+			var ce *CompileError
+			var re *RuntimeError
+			var got string
+			switch {
+			case errors.As(err, &se):
+				got = "syntax"
+			case errors.As(err, &ce):
+				got = "compile"
+			case errors.As(err, &re):
+				got = "runtime"
+			default:
+				tt.Fatalf("wanted a typed error but got %#v", err)
+			}
+			if got != tst.want {
+				tt.Errorf("wanted a %s error but got a %s error: %v", tst.want, got, err)
+			}
+		})
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	err := &SyntaxError{Span: Span{Start: Pos{"a.lak", 2, 3, 10}}, Msg: "oops", Hint: "try this"}
+	if err.Error() != "a.lak:2:3: oops. try this" {
+		t.Errorf("got %q", err.Error())
+	}
+	rerr := &RuntimeError{Msg: "oops", Hint: "try this"}
+	if rerr.Error() != "oops. try this" {
+		t.Errorf("got %q", rerr.Error())
+	}
+}
+
+func TestWrapErrorKeepsType(t *testing.T) {
+	inner := syntax_errorf(Span{Start: Pos{"", 1, 2, 1}}, "bad")
+	err := wrap_error(inner, Span{}, "while parsing")
+	var se *SyntaxError
+	if !errors.As(err, &se) || err.Error() != "1:2: while parsing. bad" {
+		t.Errorf("wanted a wrapped SyntaxError but got %#v", err)
+	}
+
+	err = wrap_error(io.EOF, Span{Start: Pos{"", 3, 1, 9}}, "while compiling")
+	var ce *CompileError
+	if !errors.As(err, &ce) || !errors.Is(err, io.EOF) || err.Error() != "3:1: while compiling. EOF" {
+		t.Errorf("wanted a CompileError wrapping EOF but got %#v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package laks
+
+import (
+	"slices"
+	"strings"
+)
+
+// Format lays source out in the standard style: one statement to a line,
+// test blocks indented by four spaces, and single spaces around binary
+// operators and after commas. Comments are kept, as are single blank lines
+// between statements. Source that does not parse is an error rather than
+// being guessed at.
+func Format(src []byte, opts ...Option) ([]byte, error) {
+	c := new_config(opts)
+	tokens, err := TokeniseFile(c.source_name, src)
+	if err != nil {
+		return nil, err
+	}
+	_, err = parse(tokens, c.max_errors)
+	if err != nil {
+		return nil, err
+	}
+
+	f := formatter{src: src, at_line_start: true, stmt_start: true}
+	end := 0
+	for _, t := range tokens {
+		f.gap(string(src[end:t.Start.Offset]))
+		f.token(t)
+		end = t.End.Offset
+	}
+	f.gap(string(src[end:]))
+	if !f.at_line_start {
+		f.out.WriteByte('\n')
+	}
+	return []byte(f.out.String()), nil
+}
+
+// formatter writes out tokens one at a time, deciding the spacing between
+// each pair. Tokens are copied from the source as they were written, so
+// numbers and strings keep their spelling.
+type formatter struct {
+	src    []byte
+	out    strings.Builder
+	indent int
+
+	prev          *Token
+	at_line_start bool // nothing has been written on the current line
+	stmt_start    bool // the next token starts a statement
+	need_newline  bool // the next token must go on a new line
+	blank_line    bool // leave a blank line before the next token
+	unary         bool // the last token was a unary minus
+}
+
+// gap handles the text between two tokens, which can only hold spaces,
+// newlines and comments.
+func (f *formatter) gap(text string) {
+	lines := strings.Split(text, "\n")
+	blank := false
+	for i, line := range lines {
+		comment := ""
+		if j := strings.IndexByte(line, '#'); j >= 0 {
+			comment = strings.TrimRight(line[j:], " \t\r")
+		}
+		switch {
+		case comment == "" && i > 0 && i < len(lines)-1:
+			blank = true
+		case comment == "":
+		case i == 0 && f.prev != nil:
+			// A comment at the end of the previous token's line.
+			f.out.WriteString(" " + comment)
+			f.at_line_start = false
+			f.need_newline = true
+		default:
+			f.newline(blank)
+			f.write_indent()
+			f.out.WriteString(comment)
+			f.at_line_start = false
+			f.need_newline = true
+			blank = false
+		}
+	}
+	if blank && f.stmt_start {
+		f.blank_line = true
+	}
+}
+
+func (f *formatter) token(t Token) {
+	if t.T == T_RBRACE {
+		f.indent = max(f.indent-1, 0)
+		f.need_newline = true
+	}
+	if f.need_newline {
+		f.newline(f.blank_line)
+	}
+	if f.at_line_start {
+		f.write_indent()
+	} else if f.space_before(t) {
+		f.out.WriteByte(' ')
+	}
+	f.out.Write(f.src[t.Start.Offset:t.End.Offset])
+
+	f.unary = t.T == T_MINUS && f.is_unary()
+	f.prev = &t
+	f.at_line_start = false
+	f.blank_line = false
+	f.stmt_start = t.T == T_SEMI || t.T == T_LBRACE || t.T == T_RBRACE
+	f.need_newline = f.stmt_start
+	if t.T == T_LBRACE {
+		f.indent++
+	}
+}
+
+// newline ends the current line, if anything is on it, with a blank line
+// after it if asked for.
+func (f *formatter) newline(blank bool) {
+	if !f.at_line_start {
+		f.out.WriteByte('\n')
+		f.at_line_start = true
+	}
+	if blank && f.out.Len() > 0 {
+		f.out.WriteByte('\n')
+	}
+	f.need_newline = false
+	f.blank_line = false
+}
+
+// write_indent indents a new line, by an extra level if it continues a
+// statement broken up by a comment.
+func (f *formatter) write_indent() {
+	indent := f.indent
+	if !f.stmt_start {
+		indent++
+	}
+	f.out.WriteString(strings.Repeat("    ", indent))
+}
+
+func (f *formatter) space_before(t Token) bool {
+	p := *f.prev
+	switch {
+	case f.unary:
+		return false
+	case t.T == T_SEMI, t.T == T_COMMA, t.T == T_RPAREN, t.T == T_DOT:
+		return false
+	case p.T == T_LPAREN, p.T == T_DOT:
+		return false
+	case t.T == T_LPAREN:
+		// A call, rather than brackets after a keyword like print.
+		calls := p.T == T_RPAREN || (p.T == T_KEYWORD && !slices.Contains(statement_keywords, p.Lexeme))
+		return !calls
+	}
+	return true
+}
+
+// is_unary reports whether a minus after the previous token negates what
+// follows rather than subtracting it.
+func (f *formatter) is_unary() bool {
+	if f.prev == nil {
+		return true
+	}
+	switch f.prev.T {
+	case T_INT, T_FLOAT, T_STRING, T_RPAREN:
+		return false
+	case T_KEYWORD:
+		return slices.Contains(statement_keywords, f.prev.Lexeme)
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package laks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatSource(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"spacing", "print   1+2 ,-3;x-1;", "print 1 + 2, -3;\nx - 1;\n"},
+		{"calls", "print len( \"a\" ) , math.pow(-1.5,2.0);", "print len(\"a\"), math.pow(-1.5, 2.0);\n"},
+		{"brackets", "print(1)*-(2);assert (true);", "print (1) * -(2);\nassert (true);\n"},
+		{"set attribute", "req.name=\"a\"+b;", "req.name = \"a\" + b;\n"},
+		{"blank lines", "print 1;\n\n\n\nprint 2;\nprint 3;", "print 1;\n\nprint 2;\nprint 3;\n"},
+		{
+			name: "comments",
+			in:   "# top\n\nprint 1;   # one\n  # two\nprint 1 + # three\n2;\n# end\n",
+			want: "# top\n\nprint 1; # one\n# two\nprint 1 + # three\n    2;\n# end\n",
+		},
+		{
+			name: "test block",
+			in:   "test \"t\"{assert 1==1,\"one\";\n\n# note\nassert true;}print 2;",
+			want: "test \"t\" {\n    assert 1 == 1, \"one\";\n\n    # note\n    assert true;\n}\nprint 2;\n",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			got, err := Format([]byte(tst.in))
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, string(got)); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+			again, err := Format(got)
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(string(got), string(again)); diff != "" {
+				tt.Errorf("formatting twice changed it (-once +twice):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatKeepsPrograms(t *testing.T) {
+	files, err := filepath.Glob("programs/*.lak")
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		got, err := Format(src)
+		if err != nil {
+			t.Fatalf("%s: %s", file, err.Error())
+		}
+		if diff := cmp.Diff(string(src), string(got)); diff != "" {
+			t.Errorf("%s is not formatted (-file +formatted):\n%s", file, diff)
+		}
+	}
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	_, err := Format([]byte("print (1;"), WithSourceName("a.lak"))
+	if err == nil || err.Error() != "a.lak:1:9: error parsing statement. error consuming. wanted 'T_RPAREN' but got 'T_SEMI'" {
+		t.Errorf("wanted a syntax error but got %v", err)
+	}
+}
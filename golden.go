@@ -0,0 +1,70 @@
+package laks
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// expectation_prefix marks a comment line holding a line of the output a
+// program is expected to print.
+const expectation_prefix = "# "
+
+// ExpectedOutput collects the expected output written into src as comments
+// starting with "# ".
+func ExpectedOutput(src []byte) string {
+	var sb strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if expected, ok := strings.CutPrefix(line, expectation_prefix); ok {
+			sb.WriteString(expected)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// UpdateExpectedOutput rewrites the expectation comments in src to match
+// output. Existing comments are replaced in order, any left over are
+// removed, and extra lines of output are added after the last one (or at the
+// end if there were none).
+func UpdateExpectedOutput(src []byte, output string) []byte {
+	lines := strings.Split(string(src), "\n")
+	var want []string
+	if output != "" {
+		want = strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	}
+
+	var updated []string
+	last := -1
+	n := 0
+	for _, line := range lines {
+		if !strings.HasPrefix(line, expectation_prefix) {
+			updated = append(updated, line)
+			continue
+		}
+		if n < len(want) {
+			updated = append(updated, expectation_prefix+want[n])
+			last = len(updated) - 1
+		}
+		n++
+	}
+
+	if n < len(want) {
+		var extra []string
+		for _, w := range want[n:] {
+			extra = append(extra, expectation_prefix+w)
+		}
+		if last < 0 {
+			end := len(updated)
+			if end > 0 && updated[end-1] == "" {
+				end--
+			}
+			last = end - 1
+		}
+		updated = append(updated[:last+1], append(extra, updated[last+1:]...)...)
+	}
+
+	return []byte(strings.Join(updated, "\n"))
+}
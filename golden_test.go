@@ -0,0 +1,66 @@
+package laks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpectedOutput(t *testing.T) {
+	src := "# 1\nprint 1;\n#not expected\n# two\n# \nprint \"two\";\n"
+	if diff := cmp.Diff("1\ntwo\n\n", ExpectedOutput([]byte(src))); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpdateExpectedOutput(t *testing.T) {
+	var tests = []struct {
+		name   string
+		src    string
+		output string
+		want   string
+	}{
+		{
+			name:   "replace in place",
+			src:    "# 1\nprint 1;\n# 3\nprint 2;\n",
+			output: "1\n2\n",
+			want:   "# 1\nprint 1;\n# 2\nprint 2;\n",
+		},
+		{
+			name:   "remove extra",
+			src:    "# 1\n# 2\nprint 1;\n",
+			output: "1\n",
+			want:   "# 1\nprint 1;\n",
+		},
+		{
+			name:   "add after last",
+			src:    "# 1\nprint 1;\nprint 2;\n",
+			output: "1\n2\n3\n",
+			want:   "# 1\n# 2\n# 3\nprint 1;\nprint 2;\n",
+		},
+		{
+			name:   "add to end",
+			src:    "print 1;\n",
+			output: "1\n",
+			want:   "print 1;\n# 1\n",
+		},
+		{
+			name:   "no output",
+			src:    "# 1\nprint 1;\n",
+			output: "",
+			want:   "print 1;\n",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			got := string(UpdateExpectedOutput([]byte(tst.src), tst.output))
+			if diff := cmp.Diff(tst.want, got); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tst.output, ExpectedOutput([]byte(got))); diff != "" {
+				tt.Errorf("updated source does not expect the output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
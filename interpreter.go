@@ -0,0 +1,82 @@
+package laks
+
+import (
+	"io"
+)
+
+// Interpreter runs one program after another on the same VM, so each sees
+// the globals left behind by the ones before it. It is what keeps state
+// between the lines of a REPL.
+type Interpreter struct {
+	bi *bytecode_interpreter
+	c  config
+}
+
+// NewInterpreter makes an interpreter whose scripts print to w. Programs
+// that embed laks can then give scripts Go functions with Register.
+func NewInterpreter(w io.Writer, opts ...Option) *Interpreter {
+	c := new_config(opts)
+	return &Interpreter{bi: new_interpreter(nil, w, c), c: c}
+}
+
+// Eval runs src. If the last statement is an expression its value is
+// returned instead of being thrown away; otherwise the result is nil.
+func (in *Interpreter) Eval(src []byte) (Value, error) {
+	stmts, err := parse_program(src, in.c)
+	if err != nil {
+		return nil, err
+	}
+
+	var last Expression
+	if len(stmts) > 0 {
+		if es, ok := stmts[len(stmts)-1].(ExpressionStatement); ok {
+			last = es.Expr
+			stmts = stmts[:len(stmts)-1]
+		}
+	}
+
+	var c compiler
+	p, err := c.program(stmts, last)
+	if err != nil {
+		return nil, err
+	}
+
+	err = in.RunProgram(p)
+	if err != nil || last == nil {
+		return nil, err
+	}
+	return in.bi.val_stack.pop(), nil
+}
+
+// Register makes fn available to scripts as a function called name. It
+// takes any number of arguments; use RegisterNative to have calls with the
+// wrong number rejected before fn is called. An error from fn stops the
+// script with a *RuntimeError naming the function.
+func (in *Interpreter) Register(name string, fn func(args []Value) (Value, error)) {
+	in.RegisterNative(&NativeFunction{Name: name, Arity: -1, Fn: fn})
+}
+
+// RegisterNative makes fn available to scripts as a function called
+// fn.Name, replacing any global of that name.
+func (in *Interpreter) RegisterNative(fn *NativeFunction) {
+	in.bi.register(fn)
+}
+
+// RunProgram runs a compiled program, with the source positions from its
+// debug info in any runtime error.
+func (in *Interpreter) RunProgram(p *Program) error {
+	in.bi.load(p)
+	in.bi.val_stack = in.bi.val_stack[:0]
+	return in.bi.run()
+}
+
+// Run executes compiled bytecode.
+func (in *Interpreter) Run(bytecode []byte) error {
+	return in.RunProgram(&Program{Code: bytecode})
+}
+
+// Repr shows a value the way the REPL and error messages do, with strings
+// in quotes.
+func Repr(v Value) string {
+	return repr_value(v)
+}
@@ -0,0 +1,127 @@
+package laks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInterpreterEval(t *testing.T) {
+	var w bytes.Buffer
+	in := NewInterpreter(&w)
+
+	var tests = []struct {
+		in   string
+		want Value
+	}{
+		{`1 + 2;`, IntValue(3)},
+		{`print "hi"; upper("a");`, StringValue("A")},
+		{`print "no value";`, nil},
+		{`"ignored"; 4;`, IntValue(4)},
+	}
+	for _, tst := range tests {
+		got, err := in.Eval([]byte(tst.in))
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if diff := cmp.Diff(tst.want, got); diff != "" {
+			t.Errorf("%s: Mismatch (-want +got):\n%s", tst.in, diff)
+		}
+	}
+
+	if diff := cmp.Diff("hi\nno value\n", w.String()); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err := in.Eval([]byte(`nope();`))
+	if err == nil {
+		t.Fatalf("wanted error")
+	}
+	got, err := in.Eval([]byte(`len("still works");`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if got != IntValue(11) {
+		t.Errorf("wanted 11 after an error but got %v", got)
+	}
+}
+
+func TestInterpreterRegister(t *testing.T) {
+	var w bytes.Buffer
+	in := NewInterpreter(&w)
+	in.RegisterNative(&NativeFunction{Name: "add", Arity: 2, Fn: func(args []Value) (Value, error) {
+		a, b := FromValue(args[0]).(int64), FromValue(args[1]).(int64)
+		return ToValue(a + b)
+	}})
+	in.Register("count", func(args []Value) (Value, error) {
+		return ToValue(len(args))
+	})
+	in.Register("nothing", func(args []Value) (Value, error) {
+		return nil, nil
+	})
+	in.Register("fail", func(args []Value) (Value, error) {
+		return nil, errors.New("no database")
+	})
+	in.Register("explode", func(args []Value) (Value, error) {
+		panic("boom")
+	})
+
+	_, err := in.Eval([]byte(`print add(1, 2), count(), count(1, "a", nil), nothing();`))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if diff := cmp.Diff("3 0 3 nil\n", w.String()); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+
+	var tests = []struct {
+		in    string
+		want  string
+		trace []Frame
+	}{
+		{
+			in:    "print 1;\nprint fail();",
+			want:  "2:1: error calling fail. no database",
+			trace: []Frame{{Name: "fail"}, {Name: main_frame, Pos: Pos{Line: 2, Col: 1, Offset: 9}}},
+		},
+		{
+			in:    `add(1);`,
+			want:  "1:1: add wants 2 arguments but got 1",
+			trace: []Frame{{Name: main_frame, Pos: Pos{Line: 1, Col: 1}}},
+		},
+		{
+			in:    `explode();`,
+			want:  "1:1: error calling explode. internal error: boom",
+			trace: []Frame{{Name: "explode"}, {Name: main_frame, Pos: Pos{Line: 1, Col: 1}}},
+		},
+	}
+	for _, tst := range tests {
+		_, err := in.Eval([]byte(tst.in))
+		var re *RuntimeError
+		if !errors.As(err, &re) {
+			t.Fatalf("%s: wanted a *RuntimeError but got %v", tst.in, err)
+		}
+		if re.Error() != tst.want {
+			t.Errorf("wanted %q but got %q", tst.want, re.Error())
+		}
+		if diff := cmp.Diff(tst.trace, re.Trace); diff != "" {
+			t.Errorf("%s: Mismatch (-want +got):\n%s", tst.in, diff)
+		}
+	}
+}
+
+func TestWithNatives(t *testing.T) {
+	var w bytes.Buffer
+	greet := &NativeFunction{Name: "greet", Arity: 1, Fn: func(args []Value) (Value, error) {
+		return ToValue("hello " + FromValue(args[0]).(string))
+	}}
+	err := RunBytes([]byte(`print greet("laks");`), &w, WithNatives(greet))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if w.String() != "hello laks\n" {
+		t.Errorf("wanted a greeting but got %q", w.String())
+	}
+}
@@ -0,0 +1,375 @@
+package laks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// A compiled program file (.lakc) is laid out as
+//
+//	magic     "LAKC"
+//	version   uint16, the format version
+//	sections  uint16 count, then for each an id byte, a uint32 length and
+//	          that many bytes of payload
+//	checksum  uint32 CRC-32 (IEEE) of everything before it
+//
+// All integers are little endian. The code section is required. The
+// constant pool and function table are always written, and the debug
+// section only when there is debug info.
+const (
+	lakc_magic   = "LAKC"
+	LakcVersion  = 1
+	lakc_header  = len(lakc_magic) + 2
+	lakc_trailer = 4
+)
+
+const (
+	section_code byte = iota + 1
+	section_constants
+	section_functions
+	section_debug
+)
+
+// Program is compiled bytecode along with everything needed to store it and
+// run it again later. OP_CONST pushes a value from Constants. The first of
+// Functions is the main program, which starts at offset 0, and the rest are
+// its test blocks, named after them.
+type Program struct {
+	Code      []byte
+	Constants []Value
+	Functions []FunctionInfo
+	Debug     *DebugInfo // nil when built without debug info
+}
+
+// FunctionInfo describes a function whose code starts at Offset in the
+// program's code and runs until OP_RETURN or the end of the code.
+type FunctionInfo struct {
+	Name   string
+	Offset int
+	Arity  int
+}
+
+// DebugInfo holds what is only needed to explain a program to people.
+type DebugInfo struct {
+	Source string // the file the program was compiled from
+	Lines  LineTable
+}
+
+// IsCompiledProgram reports whether b looks like a compiled program file
+// rather than source.
+func IsCompiledProgram(b []byte) bool {
+	return bytes.HasPrefix(b, []byte(lakc_magic))
+}
+
+func (p *Program) MarshalBinary() ([]byte, error) {
+	buf := []byte(lakc_magic)
+	buf = binary.LittleEndian.AppendUint16(buf, LakcVersion)
+
+	constants, err := encode_constants(p.Constants)
+	if err != nil {
+		return nil, err
+	}
+	sections := []struct {
+		id      byte
+		payload []byte
+	}{
+		{section_code, p.Code},
+		{section_constants, constants},
+		{section_functions, encode_functions(p.Functions)},
+	}
+	if p.Debug != nil {
+		sections = append(sections, struct {
+			id      byte
+			payload []byte
+		}{section_debug, encode_debug(p.Debug)})
+	}
+
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(sections)))
+	for _, s := range sections {
+		if uint64(len(s.payload)) > math.MaxUint32 {
+			return nil, fmt.Errorf("section %d is too large", s.id)
+		}
+		buf = append(buf, s.id)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s.payload)))
+		buf = append(buf, s.payload...)
+	}
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+// UnmarshalProgram loads a compiled program file, checking that it is
+// intact and was written in a format version this package can read.
+func UnmarshalProgram(b []byte) (*Program, error) {
+	if !IsCompiledProgram(b) {
+		return nil, errors.New("not a compiled laks program")
+	}
+	if len(b) < lakc_header+2+lakc_trailer {
+		return nil, errors.New("compiled program is truncated")
+	}
+	version := binary.LittleEndian.Uint16(b[len(lakc_magic):])
+	if version != LakcVersion {
+		return nil, fmt.Errorf("compiled program uses format version %d but this version of laks reads version %d. rebuild it from source", version, LakcVersion)
+	}
+	body := b[:len(b)-lakc_trailer]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(b[len(body):]) {
+		return nil, errors.New("compiled program is corrupt. checksum does not match")
+	}
+
+	r := lakc_reader{b: body, pos: lakc_header}
+	count, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+
+	var p Program
+	has_code := false
+	for range count {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.section()
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case section_code:
+			p.Code = payload
+			has_code = true
+		case section_constants:
+			p.Constants, err = decode_constants(payload)
+		case section_functions:
+			p.Functions, err = decode_functions(payload)
+		case section_debug:
+			p.Debug, err = decode_debug(payload)
+		default:
+			err = fmt.Errorf("unknown section %d", id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading compiled program. %v", err)
+		}
+	}
+	if r.pos != len(r.b) {
+		return nil, errors.New("error reading compiled program. unexpected data after sections")
+	}
+	if !has_code {
+		return nil, errors.New("error reading compiled program. no code section")
+	}
+	return &p, nil
+}
+
+func encode_constants(constants []Value) ([]byte, error) {
+	buf := binary.LittleEndian.AppendUint32(nil, uint32(len(constants)))
+	for _, c := range constants {
+		var err error
+		buf, err = appendValue(buf, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func decode_constants(b []byte) ([]Value, error) {
+	r := lakc_reader{b: b}
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	var constants []Value
+	for range n {
+		v, size, err := decode_value(b[r.pos:])
+		if err != nil {
+			return nil, fmt.Errorf("bad constant. %v", err)
+		}
+		constants = append(constants, v)
+		r.pos += size
+	}
+	if r.pos != len(b) {
+		return nil, errors.New("unexpected data after constants")
+	}
+	return constants, nil
+}
+
+func encode_functions(functions []FunctionInfo) []byte {
+	buf := binary.LittleEndian.AppendUint32(nil, uint32(len(functions)))
+	for _, f := range functions {
+		buf = appendString(buf, f.Name)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(f.Offset))
+		buf = append(buf, byte(f.Arity))
+	}
+	return buf
+}
+
+func decode_functions(b []byte) ([]FunctionInfo, error) {
+	r := lakc_reader{b: b}
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	var functions []FunctionInfo
+	for range n {
+		name, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		offset, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		arity, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		functions = append(functions, FunctionInfo{name, int(offset), int(arity)})
+	}
+	if r.pos != len(b) {
+		return nil, errors.New("unexpected data after functions")
+	}
+	return functions, nil
+}
+
+// encode_debug writes the source name, then the line table with the file
+// names it uses pulled out into a list so each is only written once.
+func encode_debug(d *DebugInfo) []byte {
+	buf := appendString(nil, d.Source)
+
+	var files []string
+	index := make(map[string]int)
+	for _, e := range d.Lines {
+		if _, ok := index[e.Pos.File]; !ok {
+			index[e.Pos.File] = len(files)
+			files = append(files, e.Pos.File)
+		}
+	}
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(files)))
+	for _, f := range files {
+		buf = appendString(buf, f)
+	}
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(d.Lines)))
+	for _, e := range d.Lines {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(e.Offset))
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(index[e.Pos.File]))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(e.Pos.Line))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(e.Pos.Col))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(e.Pos.Offset))
+	}
+	return buf
+}
+
+func decode_debug(b []byte) (*DebugInfo, error) {
+	r := lakc_reader{b: b}
+	source, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+	d := &DebugInfo{Source: source}
+
+	nfiles, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, nfiles)
+	for i := range files {
+		files[i], err = r.string()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	for range n {
+		offset, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		file, err := r.uint16()
+		if err != nil {
+			return nil, err
+		}
+		if int(file) >= len(files) {
+			return nil, fmt.Errorf("bad file index %d in line table", file)
+		}
+		var pos [3]uint32 // line, column and byte offset
+		for i := range pos {
+			pos[i], err = r.uint32()
+			if err != nil {
+				return nil, err
+			}
+		}
+		d.Lines = append(d.Lines, LineEntry{
+			int(offset),
+			Pos{files[file], int(pos[0]), int(pos[1]), int(pos[2])},
+		})
+	}
+	if r.pos != len(b) {
+		return nil, errors.New("unexpected data after debug info")
+	}
+	return d, nil
+}
+
+// lakc_reader reads the parts of a compiled program, failing instead of
+// reading past the end.
+type lakc_reader struct {
+	b   []byte
+	pos int
+}
+
+func (r *lakc_reader) next(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.b) {
+		return nil, errors.New("compiled program is truncated")
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *lakc_reader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *lakc_reader) uint16() (uint16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *lakc_reader) uint32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (r *lakc_reader) section() ([]byte, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	return r.next(int(n))
+}
+
+func (r *lakc_reader) string() (string, error) {
+	end := bytes.IndexByte(r.b[r.pos:], 0)
+	if end < 0 {
+		return "", errors.New("unterminated string")
+	}
+	s := string(r.b[r.pos : r.pos+end])
+	r.pos += end + 1
+	return s, nil
+}
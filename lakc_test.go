@@ -0,0 +1,92 @@
+package laks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProgramRoundTrip(t *testing.T) {
+	src := []byte("print \"hello\", 1 + 2.5;\ntest \"t\" {\n    assert \"hello\" == \"hello\";\n}")
+	code, err := CompileBytes(src)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	built, err := BuildProgram(src, WithSourceName("hello.lak"))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want_constants := []Value{StringValue("hello"), IntValue(1), FloatValue(2.5), StringValue("\"hello\" == \"hello\"")}
+	if diff := cmp.Diff(want_constants, built.Constants); diff != "" {
+		t.Errorf("constants mismatch (-want +got):\n%s", diff)
+	}
+	if len(built.Functions) != 2 || built.Functions[1].Name != "t" {
+		t.Errorf("wanted main and test \"t\" in the function table but got %v", built.Functions)
+	}
+
+	tests := []Program{{Code: code}, *built}
+	for _, tst := range tests {
+		b, err := tst.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if !IsCompiledProgram(b) {
+			t.Errorf("IsCompiledProgram is false for %q", b)
+		}
+		got, err := UnmarshalProgram(b)
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if diff := cmp.Diff(&tst, got); diff != "" {
+			t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+		}
+
+		var out bytes.Buffer
+		if err := RunProgram(got, &out); err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if out.String() != "hello 3.5\n" {
+			t.Errorf("wanted %q but got %q", "hello 3.5\n", out.String())
+		}
+	}
+}
+
+func TestUnmarshalProgramErrors(t *testing.T) {
+	p := Program{Code: []byte{byte(OP_PUSH), byte(VAL_NIL), byte(OP_PRINT)}, Debug: &DebugInfo{Source: "a.lak"}}
+	good, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	future := bytes.Clone(good)
+	binary.LittleEndian.PutUint16(future[4:], LakcVersion+1)
+	corrupt := bytes.Clone(good)
+	corrupt[len(corrupt)-6] ^= 0xff
+
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"source", []byte(`print 1;`), "not a compiled laks program"},
+		{"version", future, fmt.Sprintf("format version %d but this version of laks reads version %d", LakcVersion+1, LakcVersion)},
+		{"corrupt", corrupt, "checksum does not match"},
+		{"truncated", good[:len(good)-10], "checksum does not match"},
+		{"header only", []byte("LAKC"), "truncated"},
+	}
+
+	for _, tst := range tests {
+		_, err := UnmarshalProgram(tst.in)
+		if err == nil {
+			t.Errorf("%s: expected an error", tst.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tst.want) {
+			t.Errorf("%s: wanted error containing %q but got %q", tst.name, tst.want, err)
+		}
+	}
+}
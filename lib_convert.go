@@ -0,0 +1,80 @@
+package laks
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// conversion_functions turn values from one type into another. Conversions
+// that cannot be done, like int("abc"), are errors rather than guesses.
+var conversion_functions = []*NativeFunction{
+	{Name: "str", Arity: 1, Fn: convert_str},
+	{Name: "int", Arity: 1, Fn: convert_int},
+	{Name: "float", Arity: 1, Fn: convert_float},
+	{Name: "bool", Arity: 1, Fn: convert_bool},
+	{Name: "type", Arity: 1, Fn: convert_type},
+}
+
+func convert_str(args []Value) (Value, error) {
+	if s, ok := args[0].(StringValue); ok {
+		return s, nil
+	}
+	return StringValue(format_value(args[0])), nil
+}
+
+// convert_int truncates floats towards zero and parses strings as base 10.
+func convert_int(args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case IntValue:
+		return v, nil
+	case FloatValue:
+		f := math.Trunc(float64(v))
+		if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return nil, fmt.Errorf("cannot convert %s to an int", format_value(v))
+		}
+		return IntValue(f), nil
+	case StringValue:
+		i, err := strconv.ParseInt(strings.TrimSpace(string(v)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %s to an int", strconv.Quote(string(v)))
+		}
+		return IntValue(i), nil
+	case TrueValue:
+		return IntValue(1), nil
+	case FalseValue:
+		return IntValue(0), nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s to an int", type_name(value_type(v)))
+	}
+}
+
+func convert_float(args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case IntValue:
+		return FloatValue(v), nil
+	case FloatValue:
+		return v, nil
+	case StringValue:
+		f, err := strconv.ParseFloat(strings.TrimSpace(string(v)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %s to a float", strconv.Quote(string(v)))
+		}
+		return FloatValue(f), nil
+	case TrueValue:
+		return FloatValue(1), nil
+	case FalseValue:
+		return FloatValue(0), nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a float", type_name(value_type(v)))
+	}
+}
+
+func convert_bool(args []Value) (Value, error) {
+	return bool_value(is_truthy(args[0])), nil
+}
+
+func convert_type(args []Value) (Value, error) {
+	return StringValue(type_name(value_type(args[0]))), nil
+}
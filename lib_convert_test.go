@@ -0,0 +1,65 @@
+package laks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConversions(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print str(12) + "!";`, "12!\n"},
+		{`print str(1.0), str(true), str(nil);`, "1.0 true nil\n"},
+		{`print str(split("a", ","));`, "[\"a\"]\n"},
+		{`print int("  -42 ") + 1;`, "-41\n"},
+		{`print int(2.9), int(-2.9);`, "2 -2\n"},
+		{`print int(true), int(false);`, "1 0\n"},
+		{`print float(3), float("2.5"), float(" 1e3");`, "3.0 2.5 1000.0\n"},
+		{`print bool(0), bool(1), bool(""), bool("x"), bool(nil);`, "false true false true false\n"},
+		{`print bool(0.0), bool(chars("")), bool(chars("a"));`, "false false true\n"},
+		{`print type(1), type(1.5), type("s"), type(true), type(false);`, "int float string bool bool\n"},
+		{`print type(nil), type(chars("ab")), type(len), type(math);`, "nil list function module\n"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			var w bytes.Buffer
+			err := RunBytes([]byte(tst.in), &w)
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, w.String()); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print int("abc");`, `1:1: error calling int. cannot convert "abc" to an int`},
+		{`print int("1.5");`, `1:1: error calling int. cannot convert "1.5" to an int`},
+		{`print int(nil);`, "1:1: error calling int. cannot convert nil to an int"},
+		{`print int(math.sqrt(-1));`, "1:1: error calling int. cannot convert NaN to an int"},
+		{`print float("x");`, `1:1: error calling float. cannot convert "x" to a float`},
+		{`print float(chars("a"));`, "1:1: error calling float. cannot convert list to a float"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			err := RunBytes([]byte(tst.in), &bytes.Buffer{})
+			var re *RuntimeError
+			if !errors.As(err, &re) || re.Error() != tst.want {
+				tt.Errorf("wanted %q but got %v", tst.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package laks
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errFilesDisabled = errors.New("file system access is disabled")
+
+// file_functions are the natives for working with files. They fail unless
+// the host enabled them with WithFileAccess, and when a root was given every
+// path is resolved inside it.
+func file_functions(c config) []*NativeFunction {
+	files := &file_system{enabled: c.file_access, root: c.file_root}
+	return []*NativeFunction{
+		{Name: "read_file", Arity: 1, Fn: files.read_file},
+		{Name: "write_file", Arity: 2, Fn: files.write_file},
+		{Name: "append_file", Arity: 2, Fn: files.append_file},
+		{Name: "list_dir", Arity: 1, Fn: files.list_dir},
+		{Name: "exists", Arity: 1, Fn: files.exists},
+		{Name: "remove", Arity: 1, Fn: files.remove},
+		{Name: "mkdir", Arity: 1, Fn: files.mkdir},
+	}
+}
+
+type file_system struct {
+	enabled bool
+	root    string
+}
+
+// fsys opens the root that jailed paths resolve in, or returns nil when
+// paths are used as given. The root is opened for each call, rather than
+// kept, so that an interpreter holds no directory open between calls.
+func (f *file_system) fsys() (*os.Root, error) {
+	if !f.enabled {
+		return nil, errFilesDisabled
+	}
+	if f.root == "" {
+		return nil, nil
+	}
+	return os.OpenRoot(f.root)
+}
+
+// close_root closes a root from fsys.
+func close_root(root *os.Root) {
+	if root != nil {
+		root.Close()
+	}
+}
+
+// path reads a path argument, making it relative when it will be resolved
+// inside the jail. The caller must close the root with close_root.
+func (f *file_system) path(args []Value) (*os.Root, string, error) {
+	root, err := f.fsys()
+	if err != nil {
+		return nil, "", err
+	}
+	name, err := arg_string(args, 0)
+	if err != nil {
+		close_root(root)
+		return nil, "", err
+	}
+	if root != nil {
+		name = strings.TrimLeft(filepath.ToSlash(name), "/")
+		if name == "" {
+			name = "."
+		}
+	}
+	return root, name, nil
+}
+
+func (f *file_system) read_file(args []Value) (Value, error) {
+	root, name, err := f.path(args)
+	if err != nil {
+		return nil, err
+	}
+	defer close_root(root)
+	var b []byte
+	if root == nil {
+		b, err = os.ReadFile(name)
+	} else {
+		b, err = fs.ReadFile(root.FS(), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return StringValue(b), nil
+}
+
+func (f *file_system) write_file(args []Value) (Value, error) {
+	return f.write(args, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+}
+
+func (f *file_system) append_file(args []Value) (Value, error) {
+	return f.write(args, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+}
+
+func (f *file_system) write(args []Value, flag int) (Value, error) {
+	root, name, err := f.path(args)
+	if err != nil {
+		return nil, err
+	}
+	defer close_root(root)
+	content, err := arg_string(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var file *os.File
+	if root == nil {
+		file, err = os.OpenFile(name, flag, 0644)
+	} else {
+		file, err = root.OpenFile(name, flag, 0644)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.WriteString(content)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NilValue{}, nil
+}
+
+func (f *file_system) list_dir(args []Value) (Value, error) {
+	root, name, err := f.path(args)
+	if err != nil {
+		return nil, err
+	}
+	defer close_root(root)
+	var entries []fs.DirEntry
+	if root == nil {
+		entries, err = os.ReadDir(name)
+	} else {
+		entries, err = fs.ReadDir(root.FS(), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l := ListValue{}
+	for _, e := range entries {
+		l = append(l, StringValue(e.Name()))
+	}
+	return l, nil
+}
+
+func (f *file_system) exists(args []Value) (Value, error) {
+	root, name, err := f.path(args)
+	if err != nil {
+		return nil, err
+	}
+	defer close_root(root)
+	if root == nil {
+		_, err = os.Stat(name)
+	} else {
+		_, err = root.Stat(name)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return bool_value(false), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bool_value(true), nil
+}
+
+func (f *file_system) remove(args []Value) (Value, error) {
+	root, name, err := f.path(args)
+	if err != nil {
+		return nil, err
+	}
+	defer close_root(root)
+	if root == nil {
+		err = os.Remove(name)
+	} else {
+		err = root.Remove(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NilValue{}, nil
+}
+
+// mkdir creates a directory along with any missing parents.
+func (f *file_system) mkdir(args []Value) (Value, error) {
+	root, name, err := f.path(args)
+	if err != nil {
+		return nil, err
+	}
+	defer close_root(root)
+	if root == nil {
+		err = os.MkdirAll(name, 0755)
+		if err != nil {
+			return nil, err
+		}
+		return NilValue{}, nil
+	}
+
+	dir := ""
+	for _, part := range strings.Split(name, "/") {
+		dir = filepath.Join(dir, part)
+		err = root.Mkdir(dir, 0755)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+	}
+	return NilValue{}, nil
+}
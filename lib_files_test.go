@@ -0,0 +1,120 @@
+package laks
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileFunctions(t *testing.T) {
+	in := `
+print mkdir("out/logs");
+print exists("out/logs");
+print write_file("out/logs/a.txt", "one");
+print append_file("out/logs/a.txt", ",two");
+print read_file("out/logs/a.txt");
+print write_file("out/b.txt", "");
+print list_dir("out");
+print remove("out/b.txt");
+print exists("out/b.txt");
+`
+	want := "nil\ntrue\nnil\nnil\none,two\nnil\n[\"b.txt\", \"logs\"]\nnil\nfalse\n"
+
+	t.Run("jailed", func(tt *testing.T) {
+		var w bytes.Buffer
+		err := RunBytes([]byte(in), &w, WithFileAccess(tt.TempDir()))
+		if err != nil {
+			tt.Fatalf("%s", err.Error())
+		}
+		if diff := cmp.Diff(want, w.String()); diff != "" {
+			tt.Errorf("Mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("unrestricted", func(tt *testing.T) {
+		tt.Chdir(tt.TempDir())
+		var w bytes.Buffer
+		err := RunBytes([]byte(in), &w, WithFileAccess(""))
+		if err != nil {
+			tt.Fatalf("%s", err.Error())
+		}
+		if diff := cmp.Diff(want, w.String()); diff != "" {
+			tt.Errorf("Mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestFileFunctionsJail(t *testing.T) {
+	dir := t.TempDir()
+	jail := filepath.Join(dir, "jail")
+	err := os.Mkdir(jail, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, in := range []string{
+		`print read_file("../secret.txt");`,
+		`print read_file("/../secret.txt");`,
+		`print write_file("../escaped.txt", "x");`,
+		`print list_dir("..");`,
+	} {
+		t.Run(in, func(tt *testing.T) {
+			var w bytes.Buffer
+			if RunBytes([]byte(in), &w, WithFileAccess(jail)) == nil {
+				tt.Fatalf("wanted error but got %q", w.String())
+			}
+		})
+	}
+
+	var w bytes.Buffer
+	err = RunBytes([]byte(`print write_file("/inside.txt", "x");`), &w, WithFileAccess(jail))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if _, err := os.Stat(filepath.Join(jail, "inside.txt")); err != nil {
+		t.Errorf("absolute path should resolve inside the jail. %v", err)
+	}
+}
+
+func TestFileFunctionsDisabled(t *testing.T) {
+	for _, in := range []string{
+		`print read_file("x");`,
+		`print exists("x");`,
+		`print mkdir("x");`,
+	} {
+		t.Run(in, func(tt *testing.T) {
+			var w bytes.Buffer
+			if RunBytes([]byte(in), &w) == nil {
+				tt.Fatalf("wanted error")
+			}
+		})
+	}
+}
+
+func TestFileFunctionsCloseJail(t *testing.T) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("cannot count open files here")
+	}
+	jail := t.TempDir()
+	for range 20 {
+		err := RunBytes([]byte(`print exists("a"); print list_dir("/");`), &bytes.Buffer{}, WithFileAccess(jail))
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+	}
+	after, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) > len(fds) {
+		t.Errorf("wanted no files left open but %d more are", len(after)-len(fds))
+	}
+}
@@ -0,0 +1,294 @@
+package laks
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// format_functions are the natives for formatted output. printf writes to w
+// without adding a newline.
+func format_functions(w io.Writer) []*NativeFunction {
+	return []*NativeFunction{
+		{
+			Name:  "format",
+			Arity: -1,
+			Fn: func(args []Value) (Value, error) {
+				s, err := format_args(args)
+				if err != nil {
+					return nil, err
+				}
+				return StringValue(s), nil
+			},
+		},
+		{
+			Name:  "printf",
+			Arity: -1,
+			Fn: func(args []Value) (Value, error) {
+				s, err := format_args(args)
+				if err != nil {
+					return nil, err
+				}
+				_, err = io.WriteString(w, s)
+				if err != nil {
+					return nil, err
+				}
+				return NilValue{}, nil
+			},
+		},
+	}
+}
+
+func format_args(args []Value) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("wants a format string")
+	}
+	f, err := arg_string(args, 0)
+	if err != nil {
+		return "", err
+	}
+	return format_string(f, args[1:])
+}
+
+// format_string fills each {} in f with the next value. A placeholder may
+// carry a spec after a colon, like {:>8.2f}, made up of (all optional):
+//
+//	fill and align  an optional fill character then < (left), > (right) or ^ (centre)
+//	sign            + to always show the sign of a number
+//	zero            0 to pad numbers with zeros after the sign
+//	width           the minimum width in characters
+//	precision       .n digits after the point for floats, or characters kept for strings
+//	verb            d, b, o, x or X for ints in that radix, f or e for floats, s for strings
+//
+// Use {{ and }} for literal braces.
+func format_string(f string, args []Value) (string, error) {
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(f); i++ {
+		c := f[i]
+		if c == '}' {
+			if i+1 < len(f) && f[i+1] == '}' {
+				sb.WriteByte('}')
+				i++
+				continue
+			}
+			return "", fmt.Errorf("unmatched '}' in format string")
+		}
+		if c != '{' {
+			sb.WriteByte(c)
+			continue
+		}
+		if i+1 < len(f) && f[i+1] == '{' {
+			sb.WriteByte('{')
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(f[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed '{' in format string")
+		}
+		placeholder := f[i+1 : i+end]
+		i += end
+
+		if next >= len(args) {
+			return "", fmt.Errorf("not enough values for format string. got %d", len(args))
+		}
+		spec, ok := strings.CutPrefix(placeholder, ":")
+		if !ok && placeholder != "" {
+			return "", fmt.Errorf("bad placeholder '{%s}'", placeholder)
+		}
+		s, err := format_spec(spec, args[next])
+		if err != nil {
+			return "", fmt.Errorf("cannot format value %d. %v", next+1, err)
+		}
+		sb.WriteString(s)
+		next++
+	}
+	if next < len(args) {
+		return "", fmt.Errorf("too many values for format string. wanted %d but got %d", next, len(args))
+	}
+	return sb.String(), nil
+}
+
+type format_options struct {
+	fill      rune
+	align     byte
+	plus      bool
+	zero      bool
+	width     int
+	precision int
+	verb      byte
+}
+
+func parse_format_spec(spec string) (format_options, error) {
+	o := format_options{fill: ' ', precision: -1}
+	rest := spec
+
+	if r, size := utf8.DecodeRuneInString(rest); size > 0 && size < len(rest) && is_align(rest[size]) {
+		o.fill = r
+		o.align = rest[size]
+		rest = rest[size+1:]
+	} else if rest != "" && is_align(rest[0]) {
+		o.align = rest[0]
+		rest = rest[1:]
+	}
+	if strings.HasPrefix(rest, "+") {
+		o.plus = true
+		rest = rest[1:]
+	}
+	if strings.HasPrefix(rest, "0") {
+		o.zero = true
+		rest = rest[1:]
+	}
+
+	digits := leading_digits(rest)
+	if digits != "" {
+		o.width, _ = strconv.Atoi(digits)
+		rest = rest[len(digits):]
+	}
+	if p, ok := strings.CutPrefix(rest, "."); ok {
+		digits = leading_digits(p)
+		if digits == "" {
+			return o, fmt.Errorf("missing precision in '%s'", spec)
+		}
+		o.precision, _ = strconv.Atoi(digits)
+		rest = p[len(digits):]
+	}
+	if len(rest) == 1 && strings.Contains("dboxXfes", rest) {
+		o.verb = rest[0]
+		rest = ""
+	}
+	if rest != "" {
+		return o, fmt.Errorf("bad format spec '%s'", spec)
+	}
+	return o, nil
+}
+
+func format_spec(spec string, v Value) (string, error) {
+	o, err := parse_format_spec(spec)
+	if err != nil {
+		return "", err
+	}
+
+	var s string
+	switch o.verb {
+	case 'd', 'b', 'o', 'x', 'X':
+		i, ok := v.(IntValue)
+		if !ok {
+			return "", fmt.Errorf("'%c' wants an int but got '%s'", o.verb, format_value(v))
+		}
+		s = format_int(int64(i), o.verb)
+	case 'f', 'e':
+		if !is_number(v) {
+			return "", fmt.Errorf("'%c' wants a number but got '%s'", o.verb, format_value(v))
+		}
+		s = format_float(to_float(v), o.verb, o.precision)
+	case 's':
+		sv, ok := v.(StringValue)
+		if !ok {
+			return "", fmt.Errorf("'s' wants a string but got '%s'", format_value(v))
+		}
+		s = truncate(string(sv), o.precision)
+	default:
+		switch v := v.(type) {
+		case FloatValue:
+			if o.precision >= 0 {
+				s = format_float(float64(v), 'f', o.precision)
+			} else {
+				s = format_value(v)
+			}
+		case StringValue:
+			s = truncate(string(v), o.precision)
+		default:
+			s = format_value(v)
+		}
+	}
+
+	numeric := is_number(v)
+	if numeric && o.plus && !strings.HasPrefix(s, "-") {
+		s = "+" + s
+	}
+	return pad(s, o, numeric), nil
+}
+
+func format_int(i int64, verb byte) string {
+	switch verb {
+	case 'b':
+		return strconv.FormatInt(i, 2)
+	case 'o':
+		return strconv.FormatInt(i, 8)
+	case 'x':
+		return strconv.FormatInt(i, 16)
+	case 'X':
+		return strings.ToUpper(strconv.FormatInt(i, 16))
+	default:
+		return strconv.FormatInt(i, 10)
+	}
+}
+
+func format_float(f float64, verb byte, precision int) string {
+	if precision < 0 {
+		precision = 6
+	}
+	return strconv.FormatFloat(f, verb, precision, 64)
+}
+
+// pad widens s to the requested width. Numbers are right aligned by default
+// and everything else left aligned.
+func pad(s string, o format_options, numeric bool) string {
+	n := o.width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+
+	if numeric && o.zero && o.align == 0 {
+		sign := ""
+		if s[0] == '-' || s[0] == '+' {
+			sign, s = s[:1], s[1:]
+		}
+		return sign + strings.Repeat("0", n) + s
+	}
+
+	align := o.align
+	if align == 0 {
+		align = '<'
+		if numeric {
+			align = '>'
+		}
+	}
+	fill := string(o.fill)
+	switch align {
+	case '>':
+		return strings.Repeat(fill, n) + s
+	case '^':
+		return strings.Repeat(fill, n/2) + s + strings.Repeat(fill, n-n/2)
+	default:
+		return s + strings.Repeat(fill, n)
+	}
+}
+
+func truncate(s string, n int) string {
+	if n < 0 {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func is_align(c byte) bool {
+	return c == '<' || c == '>' || c == '^'
+}
+
+func leading_digits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,69 @@
+package laks
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormat(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print format("{} has {} items", "box", 3);`, "box has 3 items\n"},
+		{`print format("{{}} {}", true);`, "{} true\n"},
+		{`print format("[{:>5}]", 42);`, "[   42]\n"},
+		{`print format("[{:5}]", 42);`, "[   42]\n"},
+		{`print format("[{:5}]", "ab");`, "[ab   ]\n"},
+		{`print format("[{:^6}]", "ab");`, "[  ab  ]\n"},
+		{`print format("[{:*<4}]", "é");`, "[é***]\n"},
+		{`print format("[{:05}]", -42);`, "[-0042]\n"},
+		{`print format("[{:+}]", 7);`, "[+7]\n"},
+		{`print format("{:.2}", math.pi);`, "3.14\n"},
+		{`print format("{:8.3f}", 2);`, "   2.000\n"},
+		{`print format("{:e}", 1234.5);`, "1.234500e+03\n"},
+		{`print format("{:.3}", "truncated");`, "tru\n"},
+		{`print format("{:b} {:o} {:x} {:X}", 10, 8, 255, 255);`, "1010 10 ff FF\n"},
+		{`print format("{:08b}", 5);`, "00000101\n"},
+		{`print format("{}", split("a,b", ","));`, "[\"a\", \"b\"]\n"},
+		{`write printf("{}-{}", 1, 2);`, "1-2nil"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			var w bytes.Buffer
+			err := RunBytes([]byte(tst.in), &w)
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, w.String()); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	for _, in := range []string{
+		`print format();`,
+		`print format(1);`,
+		`print format("{} {}", 1);`,
+		`print format("{}", 1, 2);`,
+		`print format("{", 1);`,
+		`print format("}");`,
+		`print format("{:x}", "a");`,
+		`print format("{:f}", "a");`,
+		`print format("{:q}", 1);`,
+		`print format("{:.}", 1.5);`,
+		`print format("{0}", 1);`,
+	} {
+		t.Run(in, func(tt *testing.T) {
+			var w bytes.Buffer
+			if RunBytes([]byte(in), &w) == nil {
+				tt.Fatalf("wanted error but got %q", w.String())
+			}
+		})
+	}
+}
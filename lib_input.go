@@ -0,0 +1,92 @@
+package laks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// input_functions are the natives that read from the script's input. They
+// share r so that mixing them reads the input in order. Prompts are written
+// to w, where the script prints.
+func input_functions(r *bufio.Reader, w io.Writer) []*NativeFunction {
+	return []*NativeFunction{
+		{
+			Name:  "input",
+			Arity: -1,
+			Fn: func(args []Value) (Value, error) {
+				if len(args) > 1 {
+					return nil, fmt.Errorf("input wants 0 or 1 arguments but got %d", len(args))
+				}
+				if len(args) == 1 {
+					fmt.Fprint(w, format_value(args[0]))
+				}
+				return next_line(r)
+			},
+		},
+		{
+			Name:  "read_line",
+			Arity: 0,
+			Fn: func(args []Value) (Value, error) {
+				return next_line(r)
+			},
+		},
+		{
+			Name:  "read_all",
+			Arity: 0,
+			Fn: func(args []Value) (Value, error) {
+				b, err := io.ReadAll(r)
+				if err != nil {
+					return nil, err
+				}
+				return StringValue(b), nil
+			},
+		},
+		{
+			Name:  "lines",
+			Arity: 0,
+			Fn: func(args []Value) (Value, error) {
+				l := ListValue{}
+				for {
+					line, err := read_line(r)
+					if err == io.EOF {
+						return l, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					l = append(l, StringValue(line))
+				}
+			},
+		},
+	}
+}
+
+// next_line is the next line of input as a string, or nil once the input
+// has run out.
+func next_line(r *bufio.Reader) (Value, error) {
+	line, err := read_line(r)
+	if err == io.EOF {
+		return NilValue{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return StringValue(line), nil
+}
+
+// read_line reads up to the next newline, which is dropped along with any
+// carriage return before it. The last line does not need a newline; io.EOF
+// is only returned once there is nothing left.
+func read_line(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
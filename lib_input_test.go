@@ -0,0 +1,81 @@
+package laks
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInputFunctions(t *testing.T) {
+	var tests = []struct {
+		name  string
+		in    string
+		input string
+		want  string
+	}{
+		{
+			name:  "read_line",
+			in:    `print read_line(); print read_line(); print read_line();`,
+			input: "one\r\ntwo",
+			want:  "one\ntwo\nnil\n",
+		},
+		{
+			name:  "read_line at eof",
+			in:    `print read_line() == nil;`,
+			input: "",
+			want:  "true\n",
+		},
+		{
+			name:  "input",
+			in:    `print "hi " + input("name? "); print input(); print input("more? ");`,
+			input: "ann\nbo\n",
+			want:  "name? hi ann\nbo\nmore? nil\n",
+		},
+		{
+			name:  "read_all",
+			in:    `print read_line(); print read_all();`,
+			input: "first\nsecond\nthird",
+			want:  "first\nsecond\nthird\n",
+		},
+		{
+			name:  "lines",
+			in:    `print lines(); print lines();`,
+			input: "a\nb\n\nc\n",
+			want:  "[\"a\", \"b\", \"\", \"c\"]\n[]\n",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			var w bytes.Buffer
+			err := RunBytes([]byte(tst.in), &w, WithInput(strings.NewReader(tst.input)))
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, w.String()); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInputDefaultsToEmpty(t *testing.T) {
+	var w bytes.Buffer
+	err := RunBytes([]byte(`print read_line(); print read_all() == "";`), &w)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	if diff := cmp.Diff("nil\ntrue\n", w.String()); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInputTooManyArguments(t *testing.T) {
+	err := RunBytes([]byte(`input("a", "b");`), &bytes.Buffer{})
+	want := "1:1: error calling input. input wants 0 or 1 arguments but got 2"
+	if err == nil || err.Error() != want {
+		t.Errorf("wanted %q but got %v", want, err)
+	}
+}
@@ -0,0 +1,182 @@
+package laks
+
+import (
+	"fmt"
+	"math"
+)
+
+// math_module builds the math library. Functions that only make sense for
+// floats always return floats, while ones like abs, min and max keep ints as
+// ints.
+func math_module() *ModuleValue {
+	m := &ModuleValue{
+		Name: "math",
+		Members: map[string]Value{
+			"pi": FloatValue(math.Pi),
+			"e":  FloatValue(math.E),
+		},
+	}
+	for _, fn := range []*NativeFunction{
+		{Name: "math.sqrt", Arity: 1, Fn: float_function(math.Sqrt)},
+		{Name: "math.sin", Arity: 1, Fn: float_function(math.Sin)},
+		{Name: "math.cos", Arity: 1, Fn: float_function(math.Cos)},
+		{Name: "math.tan", Arity: 1, Fn: float_function(math.Tan)},
+		{Name: "math.log", Arity: 1, Fn: float_function(math.Log)},
+		{Name: "math.exp", Arity: 1, Fn: float_function(math.Exp)},
+		{Name: "math.floor", Arity: 1, Fn: rounding_function(math.Floor)},
+		{Name: "math.ceil", Arity: 1, Fn: rounding_function(math.Ceil)},
+		{Name: "math.round", Arity: 1, Fn: rounding_function(math.Round)},
+		{Name: "math.pow", Arity: 2, Fn: math_pow},
+		{Name: "math.abs", Arity: 1, Fn: math_abs},
+		{Name: "math.min", Arity: -1, Fn: math_min},
+		{Name: "math.max", Arity: -1, Fn: math_max},
+		{Name: "math.gcd", Arity: 2, Fn: math_gcd},
+		{Name: "math.lcm", Arity: 2, Fn: math_lcm},
+	} {
+		m.Members[fn.Name[len("math."):]] = fn
+	}
+	return m
+}
+
+func float_function(f func(float64) float64) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		x, err := arg_float(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return FloatValue(f(x)), nil
+	}
+}
+
+// rounding_function wraps floor, ceil and round, which give back an int.
+func rounding_function(f func(float64) float64) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		if i, ok := args[0].(IntValue); ok {
+			return i, nil
+		}
+		x, err := arg_float(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		r := f(x)
+		if math.IsNaN(r) || r < math.MinInt64 || r >= math.MaxInt64 {
+			return nil, fmt.Errorf("cannot round %s to an int", format_value(args[0]))
+		}
+		return IntValue(r), nil
+	}
+}
+
+func math_pow(args []Value) (Value, error) {
+	base, baseIsInt := args[0].(IntValue)
+	exp, expIsInt := args[1].(IntValue)
+	if baseIsInt && expIsInt && exp >= 0 {
+		result := IntValue(1)
+		for exp > 0 {
+			if exp&1 == 1 {
+				result *= base
+			}
+			base *= base
+			exp >>= 1
+		}
+		return result, nil
+	}
+
+	x, err := arg_float(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	y, err := arg_float(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return FloatValue(math.Pow(x, y)), nil
+}
+
+func math_abs(args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case IntValue:
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	case FloatValue:
+		return FloatValue(math.Abs(float64(v))), nil
+	default:
+		return nil, fmt.Errorf("argument 1 wants a number but got '%s'", format_value(v))
+	}
+}
+
+func math_min(args []Value) (Value, error) {
+	return pick_number(args, func(x, y float64) bool { return x < y })
+}
+
+func math_max(args []Value) (Value, error) {
+	return pick_number(args, func(x, y float64) bool { return x > y })
+}
+
+// pick_number returns the argument that beats every other by better, keeping
+// its original type.
+func pick_number(args []Value, better func(x, y float64) bool) (Value, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("wants at least 1 argument")
+	}
+	best := args[0]
+	for i := range args {
+		x, err := arg_float(args, i)
+		if err != nil {
+			return nil, err
+		}
+		if better(x, to_float(best)) {
+			best = args[i]
+		}
+	}
+	return best, nil
+}
+
+func math_gcd(args []Value) (Value, error) {
+	a, err := arg_int(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	b, err := arg_int(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return IntValue(gcd(a, b)), nil
+}
+
+func math_lcm(args []Value) (Value, error) {
+	a, err := arg_int(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	b, err := arg_int(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if a == 0 || b == 0 {
+		return IntValue(0), nil
+	}
+	l := a / gcd(a, b) * b
+	if l < 0 {
+		l = -l
+	}
+	return IntValue(l), nil
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func arg_float(args []Value, i int) (float64, error) {
+	if !is_number(args[i]) {
+		return 0, fmt.Errorf("argument %d wants a number but got '%s'", i+1, format_value(args[i]))
+	}
+	return to_float(args[i]), nil
+}
@@ -0,0 +1,80 @@
+package laks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMathModule(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print math.sqrt(16);`, "4.0\n"},
+		{`print math.pow(2, 10);`, "1024\n"},
+		{`print math.pow(2, -1);`, "0.5\n"},
+		{`print math.pow(2.5, 2);`, "6.25\n"},
+		{`print math.abs(-5);`, "5\n"},
+		{`print math.abs(-2.5);`, "2.5\n"},
+		{`print math.min(3, 1.5, 2);`, "1.5\n"},
+		{`print math.max(3, 1, 2);`, "3\n"},
+		{`print math.floor(2.7);`, "2\n"},
+		{`print math.ceil(2.2);`, "3\n"},
+		{`print math.round(-2.5);`, "-3\n"},
+		{`print math.round(7);`, "7\n"},
+		{`print math.sin(0);`, "0.0\n"},
+		{`print math.cos(0);`, "1.0\n"},
+		{`print math.tan(0);`, "0.0\n"},
+		{`print math.log(math.e);`, "1.0\n"},
+		{`print math.exp(0);`, "1.0\n"},
+		{`print math.pi;`, "3.141592653589793\n"},
+		{`print math.gcd(12, -18);`, "6\n"},
+		{`print math.lcm(4, 6);`, "12\n"},
+		{`print math.lcm(0, 6);`, "0\n"},
+		{`print 1.5 + 1;`, "2.5\n"},
+		{`print 3 / 2;`, "1\n"},
+		{`print 3.0 / 2;`, "1.5\n"},
+		{`print 2.0 == 2;`, "true\n"},
+		{`print -(1 + 2) * 3;`, "-9\n"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			var w bytes.Buffer
+			err := RunBytes([]byte(tst.in), &w)
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, w.String()); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMathModuleErrors(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print math.sqrt("4");`, "1:1: error calling math.sqrt. argument 1 wants a number but got '4'"},
+		{`print math.min();`, "1:1: error calling math.min. wants at least 1 argument"},
+		{`print math.gcd(1.5, 2);`, "1:1: error calling math.gcd. argument 1 wants an int but got '1.5'"},
+		{`print math.floor(math.sqrt(-1));`, "1:1: error calling math.floor. cannot round NaN to an int"},
+		{`print math.nope;`, "1:1: module math has no member 'nope'"},
+		{`print "a".b;`, "1:1: cannot read attribute 'b' of 'a'"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			err := RunBytes([]byte(tst.in), &bytes.Buffer{})
+			var re *RuntimeError
+			if !errors.As(err, &re) || re.Error() != tst.want {
+				tt.Errorf("wanted %q but got %v", tst.want, err)
+			}
+		})
+	}
+}
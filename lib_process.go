@@ -0,0 +1,30 @@
+package laks
+
+import (
+	"fmt"
+)
+
+// process_functions control the script as a whole.
+var process_functions = []*NativeFunction{
+	{Name: "exit", Arity: -1, Fn: process_exit},
+}
+
+// process_exit stops the script with exit(n), or exit() for status 0. Codes
+// outside 0 to 255 are an error, since the system would wrap them around and
+// could turn a failure into success.
+func process_exit(args []Value) (Value, error) {
+	if len(args) == 0 {
+		return nil, &ExitError{0}
+	}
+	if len(args) > 1 {
+		return nil, fmt.Errorf("exit wants 0 or 1 arguments but got %d", len(args))
+	}
+	code, ok := args[0].(IntValue)
+	if !ok {
+		return nil, fmt.Errorf("exit wants an int but got %s", type_name(value_type(args[0])))
+	}
+	if code < 0 || code > 255 {
+		return nil, fmt.Errorf("exit code must be from 0 to 255 but got %d", code)
+	}
+	return nil, &ExitError{int(code)}
+}
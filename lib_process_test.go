@@ -0,0 +1,58 @@
+package laks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestExit(t *testing.T) {
+	var tests = []struct {
+		in   string
+		code int
+		out  string
+	}{
+		{`print 1; exit(3); print 2;`, 3, "1\n"},
+		{`exit();`, 0, ""},
+		{`print upper(exit(7));`, 7, ""},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			var w bytes.Buffer
+			err := RunBytes([]byte(tst.in), &w)
+			var exit *ExitError
+			if !errors.As(err, &exit) {
+				tt.Fatalf("wanted an *ExitError but got %v", err)
+			}
+			if exit.Code != tst.code {
+				tt.Errorf("wanted code %d but got %d", tst.code, exit.Code)
+			}
+			if w.String() != tst.out {
+				tt.Errorf("wanted output %q but got %q", tst.out, w.String())
+			}
+		})
+	}
+}
+
+func TestExitErrors(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`exit("a");`, "1:1: error calling exit. exit wants an int but got string"},
+		{`exit(1, 2);`, "1:1: error calling exit. exit wants 0 or 1 arguments but got 2"},
+		{`exit(256);`, "1:1: error calling exit. exit code must be from 0 to 255 but got 256"},
+		{`exit(-1);`, "1:1: error calling exit. exit code must be from 0 to 255 but got -1"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			err := RunBytes([]byte(tst.in), &bytes.Buffer{})
+			var re *RuntimeError
+			if !errors.As(err, &re) || re.Error() != tst.want {
+				tt.Errorf("wanted %q but got %v", tst.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package laks
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// string_functions are the natives for working with strings. Lengths and
+// indexes count runes rather than bytes so they behave for any UTF-8 text.
+var string_functions = []*NativeFunction{
+	{Name: "len", Arity: 1, Fn: str_len},
+	{Name: "upper", Arity: 1, Fn: str_upper},
+	{Name: "lower", Arity: 1, Fn: str_lower},
+	{Name: "trim", Arity: 1, Fn: str_trim},
+	{Name: "split", Arity: 2, Fn: str_split},
+	{Name: "join", Arity: 2, Fn: str_join},
+	{Name: "contains", Arity: 2, Fn: str_contains},
+	{Name: "starts_with", Arity: 2, Fn: str_starts_with},
+	{Name: "ends_with", Arity: 2, Fn: str_ends_with},
+	{Name: "replace", Arity: 3, Fn: str_replace},
+	{Name: "index_of", Arity: 2, Fn: str_index_of},
+	{Name: "substring", Arity: 3, Fn: str_substring},
+	{Name: "repeat", Arity: 2, Fn: str_repeat},
+	{Name: "chars", Arity: 1, Fn: str_chars},
+}
+
+func str_len(args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case StringValue:
+		return IntValue(utf8.RuneCountInString(string(v))), nil
+	case ListValue:
+		return IntValue(len(v)), nil
+	default:
+		return nil, fmt.Errorf("argument 1 wants a string or list but got '%s'", format_value(v))
+	}
+}
+
+func str_upper(args []Value) (Value, error) {
+	s, err := arg_string(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return StringValue(strings.ToUpper(s)), nil
+}
+
+func str_lower(args []Value) (Value, error) {
+	s, err := arg_string(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return StringValue(strings.ToLower(s)), nil
+}
+
+func str_trim(args []Value) (Value, error) {
+	s, err := arg_string(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return StringValue(strings.TrimSpace(s)), nil
+}
+
+func str_split(args []Value) (Value, error) {
+	s, err := arg_string(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := arg_string(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	var l ListValue
+	for _, part := range strings.Split(s, sep) {
+		l = append(l, StringValue(part))
+	}
+	return l, nil
+}
+
+func str_join(args []Value) (Value, error) {
+	l, ok := args[0].(ListValue)
+	if !ok {
+		return nil, fmt.Errorf("argument 1 wants a list but got '%s'", format_value(args[0]))
+	}
+	sep, err := arg_string(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(l))
+	for i, e := range l {
+		s, ok := e.(StringValue)
+		if !ok {
+			return nil, fmt.Errorf("can only join strings but element %d is '%s'", i, format_value(e))
+		}
+		parts[i] = string(s)
+	}
+	return StringValue(strings.Join(parts, sep)), nil
+}
+
+func str_contains(args []Value) (Value, error) {
+	s, sub, err := arg_strings(args)
+	if err != nil {
+		return nil, err
+	}
+	return bool_value(strings.Contains(s, sub)), nil
+}
+
+func str_starts_with(args []Value) (Value, error) {
+	s, prefix, err := arg_strings(args)
+	if err != nil {
+		return nil, err
+	}
+	return bool_value(strings.HasPrefix(s, prefix)), nil
+}
+
+func str_ends_with(args []Value) (Value, error) {
+	s, suffix, err := arg_strings(args)
+	if err != nil {
+		return nil, err
+	}
+	return bool_value(strings.HasSuffix(s, suffix)), nil
+}
+
+func str_replace(args []Value) (Value, error) {
+	s, old, err := arg_strings(args)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := arg_string(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	return StringValue(strings.ReplaceAll(s, old, replacement)), nil
+}
+
+func str_index_of(args []Value) (Value, error) {
+	s, sub, err := arg_strings(args)
+	if err != nil {
+		return nil, err
+	}
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return IntValue(-1), nil
+	}
+	return IntValue(utf8.RuneCountInString(s[:i])), nil
+}
+
+// str_substring slices by rune index. Negative indexes count back from the
+// end of the string.
+func str_substring(args []Value) (Value, error) {
+	s, err := arg_string(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, err := arg_int(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	end, err := arg_int(args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(s)
+	n := int64(len(runes))
+	if start < 0 {
+		start += n
+	}
+	if end < 0 {
+		end += n
+	}
+	if start < 0 || end > n || start > end {
+		return nil, fmt.Errorf("range %d to %d is out of bounds for string of length %d", args[1], args[2], n)
+	}
+	return StringValue(string(runes[start:end])), nil
+}
+
+func str_repeat(args []Value) (Value, error) {
+	s, err := arg_string(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	n, err := arg_int(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("cannot repeat a negative number of times")
+	}
+	return StringValue(strings.Repeat(s, int(n))), nil
+}
+
+func str_chars(args []Value) (Value, error) {
+	s, err := arg_string(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	l := ListValue{}
+	for _, r := range s {
+		l = append(l, StringValue(string(r)))
+	}
+	return l, nil
+}
+
+func arg_string(args []Value, i int) (string, error) {
+	s, ok := args[i].(StringValue)
+	if !ok {
+		return "", fmt.Errorf("argument %d wants a string but got '%s'", i+1, format_value(args[i]))
+	}
+	return string(s), nil
+}
+
+func arg_strings(args []Value) (string, string, error) {
+	a, err := arg_string(args, 0)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := arg_string(args, 1)
+	return a, b, err
+}
+
+func arg_int(args []Value, i int) (int64, error) {
+	n, ok := args[i].(IntValue)
+	if !ok {
+		return 0, fmt.Errorf("argument %d wants an int but got '%s'", i+1, format_value(args[i]))
+	}
+	return int64(n), nil
+}
@@ -0,0 +1,73 @@
+package laks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStringFunctions(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print len("héllo");`, "5\n"},
+		{`print len(split("a b", " "));`, "2\n"},
+		{`print upper("héllo");`, "HÉLLO\n"},
+		{`print lower("HÉLLO");`, "héllo\n"},
+		{`print trim("  x y  ");`, "x y\n"},
+		{`print split("a,b,,c", ",");`, "[\"a\", \"b\", \"\", \"c\"]\n"},
+		{`print join(split("a,b,c", ","), " - ");`, "a - b - c\n"},
+		{`print contains("haystack", "st");`, "true\n"},
+		{`print starts_with("haystack", "hay");`, "true\n"},
+		{`print ends_with("haystack", "hay");`, "false\n"},
+		{`print replace("a.b.c", ".", "::");`, "a::b::c\n"},
+		{`print index_of("日本語", "語");`, "2\n"},
+		{`print index_of("abc", "z");`, "-1\n"},
+		{`print substring("日本語テキスト", 1, 3);`, "本語\n"},
+		{`print substring("hello", 0 - 3, 0 - 1);`, "ll\n"},
+		{`print repeat("ab", 3);`, "ababab\n"},
+		{`print chars("né");`, "[\"n\", \"é\"]\n"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			var w bytes.Buffer
+			err := RunBytes([]byte(tst.in), &w)
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, w.String()); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStringFunctionErrors(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`print len(1);`, "1:1: error calling len. argument 1 wants a string or list but got '1'"},
+		{`print upper("a", "b");`, "1:1: upper wants 1 arguments but got 2"},
+		{`print substring("abc", 2, 1);`, "1:1: error calling substring. range 2 to 1 is out of bounds for string of length 3"},
+		{`print substring("abc", 0, 4);`, "1:1: error calling substring. range 0 to 4 is out of bounds for string of length 3"},
+		{`print repeat("a", 0 - 1);`, "1:1: error calling repeat. cannot repeat a negative number of times"},
+		{`print join(chars("ab"), 1);`, "1:1: error calling join. argument 2 wants a string but got '1'"},
+		{`print nope("a");`, "1:1: undefined name 'nope'"},
+		{`print "a"("b");`, "1:1: cannot call 'a'"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.in, func(tt *testing.T) {
+			err := RunBytes([]byte(tst.in), &bytes.Buffer{})
+			var re *RuntimeError
+			if !errors.As(err, &re) || re.Error() != tst.want {
+				tt.Errorf("wanted %q but got %v", tst.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package laks
+
+import (
+	"sort"
+)
+
+// LineTable maps offsets in compiled code back to the source they were
+// compiled from. Each entry covers the code from its offset up to the next
+// entry's, so there is only an entry where the position changes.
+type LineTable []LineEntry
+
+type LineEntry struct {
+	Offset int
+	Pos    Pos
+}
+
+// Lookup returns the position of the code at ip.
+func (t LineTable) Lookup(ip int) (Pos, bool) {
+	i := sort.Search(len(t), func(i int) bool { return t[i].Offset > ip })
+	if i == 0 {
+		return Pos{}, false
+	}
+	return t[i-1].Pos, true
+}
+
+func (t *LineTable) add(offset int, pos Pos) {
+	if !pos.IsValid() {
+		return
+	}
+	if n := len(*t); n > 0 {
+		last := &(*t)[n-1]
+		if last.Pos == pos {
+			return
+		}
+		if last.Offset == offset {
+			last.Pos = pos
+			return
+		}
+	}
+	*t = append(*t, LineEntry{offset, pos})
+}
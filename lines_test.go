@@ -0,0 +1,77 @@
+package laks
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLineTableLookup(t *testing.T) {
+	var table LineTable
+	table.add(0, Pos{"a.lak", 1, 1, 0})
+	table.add(4, Pos{"a.lak", 1, 1, 0})
+	table.add(4, Pos{})
+	table.add(9, Pos{"a.lak", 2, 1, 9})
+	table.add(9, Pos{"a.lak", 3, 1, 12})
+
+	want := LineTable{{0, Pos{"a.lak", 1, 1, 0}}, {9, Pos{"a.lak", 3, 1, 12}}}
+	if diff := cmp.Diff(want, table); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		ip   int
+		line int
+	}{{0, 1}, {8, 1}, {9, 3}, {100, 3}}
+	for _, tst := range tests {
+		pos, ok := table.Lookup(tst.ip)
+		if !ok || pos.Line != tst.line {
+			t.Errorf("ip %d: wanted line %d but got %v", tst.ip, tst.line, pos)
+		}
+	}
+	if _, ok := (LineTable{}).Lookup(0); ok {
+		t.Errorf("wanted nothing from an empty table")
+	}
+}
+
+func TestRuntimeErrorPosition(t *testing.T) {
+	err := RunBytes([]byte("print 1;\n\nprint nope;"), io.Discard, WithSourceName("main.lak"))
+	want := "main.lak:3:1: undefined name 'nope'"
+	if err == nil || err.Error() != want {
+		t.Fatalf("wanted error %q but got %v", want, err)
+	}
+}
+
+func TestRuntimeErrorTrace(t *testing.T) {
+	err := RunBytes([]byte("print 1;\nprint int(\"x\");"), io.Discard, WithSourceName("main.lak"))
+	var re *RuntimeError
+	if !errors.As(err, &re) {
+		t.Fatalf("wanted a *RuntimeError but got %v", err)
+	}
+
+	call_site := Pos{"main.lak", 2, 1, 9}
+	want := []Frame{{"int", Pos{}}, {"<main>", call_site}}
+	if diff := cmp.Diff(want, re.Trace); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+	if re.Pos != call_site {
+		t.Errorf("wanted the error at %v but got %v", call_site, re.Pos)
+	}
+	trace := "\tat int (native)\n\tat <main> (main.lak:2:1)\n"
+	if re.StackTrace() != trace {
+		t.Errorf("wanted stack trace %q but got %q", trace, re.StackTrace())
+	}
+}
+
+func TestRuntimeErrorWithoutLines(t *testing.T) {
+	bytecode, err := CompileBytes([]byte("print nope;"))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	err = Run(bytecode, io.Discard)
+	if err == nil || err.Error() != "undefined name 'nope'" {
+		t.Errorf("wanted an error without a position but got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package laks
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+)
+
+// ResolveImports replaces each import statement with the statements of the
+// module it names. Modules are read from fsys, with paths taken relative to
+// the directory of the importing module. A module is only ever included once,
+// so repeated and circular imports are harmless.
+func ResolveImports(stmts []Statement, fsys fs.FS) ([]Statement, error) {
+	return resolve_imports(stmts, fsys, "", DefaultMaxErrors)
+}
+
+// resolve_imports is ResolveImports for a program read from file, so that
+// positions in modules are named relative to the same place. Modules are
+// parsed reporting up to max_errors syntax errors, as for WithMaxErrors.
+func resolve_imports(stmts []Statement, fsys fs.FS, file string, max_errors int) ([]Statement, error) {
+	l := loader{fsys: fsys, seen: make(map[string]bool), max_errors: max_errors}
+	if file != "" {
+		l.file_dir = filepath.Dir(file)
+	}
+	return l.resolve(stmts, ".")
+}
+
+type loader struct {
+	fsys       fs.FS
+	seen       map[string]bool
+	file_dir   string // where fsys is, for naming modules in positions
+	max_errors int
+}
+
+func (l *loader) resolve(stmts []Statement, dir string) ([]Statement, error) {
+	var resolved []Statement
+	for _, stmt := range stmts {
+		imp, ok := stmt.(ImportStatement)
+		if !ok {
+			resolved = append(resolved, stmt)
+			continue
+		}
+		module, err := l.load(path.Join(dir, imp.Path))
+		if err != nil {
+			return resolved, wrap_error(err, imp.Span, "error importing '%s'", imp.Path)
+		}
+		resolved = append(resolved, module...)
+	}
+	return resolved, nil
+}
+
+func (l *loader) load(name string) ([]Statement, error) {
+	if l.fsys == nil {
+		return nil, fmt.Errorf("no module loader configured")
+	}
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("invalid module path '%s'", name)
+	}
+	if l.seen[name] {
+		return nil, nil
+	}
+	l.seen[name] = true
+
+	src, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := TokeniseFile(filepath.Join(l.file_dir, filepath.FromSlash(name)), src)
+	if err != nil {
+		return nil, wrap_error(err, Span{}, "error tokenising module '%s'", name)
+	}
+	stmts, err := parse(tokens, l.max_errors)
+	if err != nil {
+		return nil, wrap_error(err, Span{}, "error parsing module '%s'", name)
+	}
+	return l.resolve(stmts, path.Dir(name))
+}
@@ -0,0 +1,82 @@
+package laks
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImports(t *testing.T) {
+	modules := fstest.MapFS{
+		"greet.lak":      {Data: []byte(`print "hello";`)},
+		"lib/outer.lak":  {Data: []byte(`import "inner.lak"; print "outer";`)},
+		"lib/inner.lak":  {Data: []byte(`print "inner";`)},
+		"cycle/a.lak":    {Data: []byte(`import "b.lak"; print "a";`)},
+		"cycle/b.lak":    {Data: []byte(`import "a.lak"; print "b";`)},
+		"broken.lak":     {Data: []byte(`print 1`)},
+		"lib/escape.lak": {Data: []byte(`import "../../greet.lak";`)},
+	}
+
+	var tests = []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "simple",
+			in:   `import "greet.lak"; print "world";`,
+			want: "hello\nworld\n",
+		},
+		{
+			name: "relative to importer",
+			in:   `import "lib/outer.lak";`,
+			want: "inner\nouter\n",
+		},
+		{
+			name: "only once",
+			in:   `import "greet.lak"; import "greet.lak";`,
+			want: "hello\n",
+		},
+		{
+			name: "cycle",
+			in:   `import "cycle/a.lak";`,
+			want: "b\na\n",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			var w bytes.Buffer
+			err := RunBytes([]byte(tst.in), &w, WithModules(modules))
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, w.String()); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, in := range []string{
+		`import "missing.lak";`,
+		`import "broken.lak";`,
+		`import "lib/escape.lak";`,
+	} {
+		t.Run(in, func(tt *testing.T) {
+			var w bytes.Buffer
+			if RunBytes([]byte(in), &w, WithModules(modules)) == nil {
+				tt.Fatalf("wanted error")
+			}
+		})
+	}
+}
+
+func TestImportsWithoutLoader(t *testing.T) {
+	var w bytes.Buffer
+	err := RunBytes([]byte(`import "greet.lak";`), &w)
+	if err == nil {
+		t.Fatalf("wanted error")
+	}
+}
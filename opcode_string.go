@@ -15,11 +15,23 @@ func _() {
 	_ = x[OP_DIV-4]
 	_ = x[OP_MINUS-5]
 	_ = x[OP_EQ-6]
+	_ = x[OP_GET_GLOBAL-7]
+	_ = x[OP_CALL-8]
+	_ = x[OP_NEGATE-9]
+	_ = x[OP_GET_ATTR-10]
+	_ = x[OP_PRINTN-11]
+	_ = x[OP_WRITE-12]
+	_ = x[OP_POP-13]
+	_ = x[OP_ASSERT-14]
+	_ = x[OP_ASSERT_CMP-15]
+	_ = x[OP_SET_ATTR-16]
+	_ = x[OP_CONST-17]
+	_ = x[OP_RETURN-18]
 }
 
-const _OpCode_name = "OP_PUSHOP_ADDOP_MULTOP_PRINTOP_DIVOP_MINUSOP_EQ"
+const _OpCode_name = "OP_PUSHOP_ADDOP_MULTOP_PRINTOP_DIVOP_MINUSOP_EQOP_GET_GLOBALOP_CALLOP_NEGATEOP_GET_ATTROP_PRINTNOP_WRITEOP_POPOP_ASSERTOP_ASSERT_CMPOP_SET_ATTROP_CONSTOP_RETURN"
 
-var _OpCode_index = [...]uint8{0, 7, 13, 20, 28, 34, 42, 47}
+var _OpCode_index = [...]uint8{0, 7, 13, 20, 28, 34, 42, 47, 60, 67, 76, 87, 96, 104, 110, 119, 132, 143, 151, 160}
 
 func (i OpCode) String() string {
 	if i >= OpCode(len(_OpCode_index)-1) {
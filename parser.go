@@ -1,8 +1,11 @@
 package laks
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 //go:generate stringer -type=BinaryOperator
@@ -16,83 +19,456 @@ const (
 	BO_EQ
 )
 
-type Statement any
+//go:generate stringer -type=UnaryOperator
+type UnaryOperator byte
+
+const (
+	UO_NEGATE UnaryOperator = iota
+)
+
+// Statement is a node that is run for its effect.
+type Statement interface {
+	statement_node()
+	span() Span
+}
+
+// Expression is a node that produces a value.
+type Expression interface {
+	expression_node()
+	span() Span
+}
 
 type PrintStatment struct {
-	Expr Statement
+	Exprs []Expression
+	Span
+}
+
+type WriteStatement struct {
+	Exprs []Expression
+	Span
+}
+
+type ImportStatement struct {
+	Path string
+	Span
+}
+
+// AssertStatement fails the program when Expr is not truthy. Message is
+// optional.
+type AssertStatement struct {
+	Expr    Expression
+	Message Expression
+	Span
+}
+
+// TestStatement is a named block that is only run by the test runner.
+type TestStatement struct {
+	Name string
+	Body []Statement
+	Span
+}
+
+// SetAttrStatement sets the attribute Name of Object to Value, as in
+// 'req.name = "x";'.
+type SetAttrStatement struct {
+	Object Expression
+	Name   string
+	Value  Expression
+	Span
+}
+
+// ExpressionStatement evaluates an expression and throws the result away.
+type ExpressionStatement struct {
+	Expr Expression
+	Span
 }
 
 type BinaryExpression struct {
 	Op    BinaryOperator
-	Left  Statement
-	Right Statement
+	Left  Expression
+	Right Expression
+	Span
 }
 
 type LiteralExpression struct {
 	Value Value
+	Span
+}
+
+type UnaryExpression struct {
+	Op   UnaryOperator
+	Expr Expression
+	Span
+}
+
+type GetAttrExpression struct {
+	Object Expression
+	Name   string
+	Span
 }
 
+type VariableExpression struct {
+	Name string
+	Span
+}
+
+type CallExpression struct {
+	Callee Expression
+	Args   []Expression
+	Span
+}
+
+func (PrintStatment) statement_node()       {}
+func (WriteStatement) statement_node()      {}
+func (ImportStatement) statement_node()     {}
+func (AssertStatement) statement_node()     {}
+func (TestStatement) statement_node()       {}
+func (SetAttrStatement) statement_node()    {}
+func (ExpressionStatement) statement_node() {}
+
+func (BinaryExpression) expression_node()   {}
+func (LiteralExpression) expression_node()  {}
+func (UnaryExpression) expression_node()    {}
+func (GetAttrExpression) expression_node()  {}
+func (VariableExpression) expression_node() {}
+func (CallExpression) expression_node()     {}
+
+// expression_source renders an expression back into source code.
+func expression_source(expr Expression) string {
+	switch e := expr.(type) {
+	case BinaryExpression:
+		left := expression_source(e.Left)
+		if l, ok := e.Left.(BinaryExpression); ok && precedence(l.Op) < precedence(e.Op) {
+			left = "(" + left + ")"
+		}
+		right := expression_source(e.Right)
+		if r, ok := e.Right.(BinaryExpression); ok && precedence(r.Op) <= precedence(e.Op) {
+			right = "(" + right + ")"
+		}
+		return fmt.Sprintf("%s %s %s", left, binary_op_source(e.Op), right)
+	case UnaryExpression:
+		return "-" + operand_source(e.Expr)
+	case LiteralExpression:
+		if s, ok := e.Value.(StringValue); ok {
+			return strconv.Quote(string(s))
+		}
+		return format_value(e.Value)
+	case VariableExpression:
+		return e.Name
+	case GetAttrExpression:
+		return operand_source(e.Object) + "." + e.Name
+	case CallExpression:
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = expression_source(arg)
+		}
+		return operand_source(e.Callee) + "(" + strings.Join(args, ", ") + ")"
+	default:
+		return fmt.Sprintf("%v", e)
+	}
+}
+
+// operand_source renders the operand of a unary operator or call, adding
+// brackets around any binary expression.
+func operand_source(expr Expression) string {
+	if _, ok := expr.(BinaryExpression); ok {
+		return "(" + expression_source(expr) + ")"
+	}
+	return expression_source(expr)
+}
+
+// precedence ranks operators by how tightly they bind, matching the order
+// they are parsed in.
+func precedence(op BinaryOperator) int {
+	switch op {
+	case BO_EQ:
+		return 1
+	case BO_ADD, BO_MINUS:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func binary_op_source(op BinaryOperator) string {
+	switch op {
+	case BO_ADD:
+		return "+"
+	case BO_MINUS:
+		return "-"
+	case BO_MULT:
+		return "*"
+	case BO_DIV:
+		return "/"
+	case BO_EQ:
+		return "=="
+	default:
+		return op.String()
+	}
+}
+
+// Parse turns tokens into statements. It carries on past syntax errors so
+// that it can report as many as possible, up to DefaultMaxErrors, as
+// SyntaxErrors.
 func Parse(tokens []Token) ([]Statement, error) {
-	p := parser{tokens: tokens}
-	return p.parse()
+	return parse(tokens, DefaultMaxErrors)
+}
+
+// DefaultMaxErrors is how many syntax errors are reported before giving
+// up, unless WithMaxErrors says otherwise.
+const DefaultMaxErrors = 10
+
+func parse(tokens []Token, max_errors int) ([]Statement, error) {
+	p := parser{tokens: tokens, max_errors: max_errors}
+	stmts := p.parse()
+	if len(p.errors) > 0 {
+		return stmts, p.errors
+	}
+	return stmts, nil
 }
 
 type parser struct {
-	tokens []Token
-	curr   int
+	tokens     []Token
+	curr       int
+	errors     SyntaxErrors
+	max_errors int // 0 means no limit
 }
 
-func (p *parser) parse() ([]Statement, error) {
-	var exprs []Statement
-	for p.curr < len(p.tokens) {
-		expr, err := p.parse_statement()
+func (p *parser) parse() []Statement {
+	var stmts []Statement
+	for p.curr < len(p.tokens) && !p.gave_up() {
+		start := p.curr
+		stmt, err := p.parse_statement()
 		if err != nil {
-			return exprs, err
+			p.recover_from(err, start)
+			continue
 		}
-		exprs = append(exprs, expr)
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// recover_from records a syntax error from a statement that began at token
+// start, then skips to where the next statement should begin: past a ';',
+// or up to a statement keyword or the end of a block.
+func (p *parser) recover_from(err error, start int) {
+	var se *SyntaxError
+	if !errors.As(err, &se) {
+		se = &SyntaxError{Span: p.tokens[start].Span, Msg: err.Error(), Err: err}
+	}
+	p.errors = append(p.errors, se)
+	if p.gave_up() {
+		p.errors = append(p.errors, &SyntaxError{Span: se.Span, Msg: "too many errors"})
+	}
+
+	if p.curr == start {
+		p.read()
+	}
+	if p.tokens[p.curr-1].T == T_SEMI {
+		// The statement failed on its own ';', so the next one starts here.
+		return
 	}
-	return exprs, nil
+	for p.curr < len(p.tokens) {
+		t := p.peek()
+		if t.T == T_SEMI {
+			p.read()
+			return
+		}
+		if t.T == T_RBRACE || (t.T == T_KEYWORD && slices.Contains(statement_keywords, t.Lexeme)) {
+			return
+		}
+		p.read()
+	}
+}
+
+// gave_up reports whether there have been too many errors to carry on.
+func (p *parser) gave_up() bool {
+	return p.max_errors > 0 && len(p.errors) >= p.max_errors
 }
 
+// statement_keywords are the keywords that start a statement. Anything else
+// starts an expression statement.
+var statement_keywords = []string{"print", "write", "import", "assert", "test"}
+
 func (p *parser) parse_statement() (Statement, error) {
 	t := p.peek()
 	var stmt Statement
 	var err error
-	switch t.T {
-	case T_INT:
-		stmt, err = p.parse_bools()
-	case T_KEYWORD:
+	if t.T == T_KEYWORD && t.Lexeme == "test" {
+		return p.parse_test()
+	} else if t.T == T_KEYWORD && slices.Contains(statement_keywords, t.Lexeme) {
 		stmt, err = p.parse_keyword()
-	default:
-		return stmt, fmt.Errorf("do not know how to handle '%#v'", t)
+	} else if hint := p.misspelled_keyword(); hint != "" {
+		kwd_err := syntax_errorf(t.Span, "do not recognise keyword '%v'", t.Lexeme)
+		kwd_err.Label, kwd_err.Hint = "unknown keyword", hint
+		err = kwd_err
+	} else {
+		var expr Expression
+		expr, err = p.parse_bools()
+		if err == nil {
+			stmt, err = p.parse_expression_statement(expr)
+		}
 	}
 
 	if err != nil {
-		return stmt, fmt.Errorf("error parsing statement. %s", err)
+		return nil, wrap_error(err, t.Span, "error parsing statement")
 	}
 
 	err = p.consume(T_SEMI)
 	if err != nil {
-		err = fmt.Errorf("error parsing statement. %v", err)
+		err = wrap_error(err, t.Span, "error parsing statement")
 	}
 	return stmt, err
 }
 
+// misspelled_keyword returns a hint if the statement starts with a name
+// that looks like a misspelled statement keyword. A name followed straight
+// away by the start of an expression cannot begin an expression statement,
+// so only then is it taken to be a keyword.
+func (p *parser) misspelled_keyword() string {
+	if p.curr+1 >= len(p.tokens) {
+		return ""
+	}
+	t, next := p.tokens[p.curr], p.tokens[p.curr+1]
+	if t.T != T_KEYWORD {
+		return ""
+	}
+	switch next.T {
+	case T_INT, T_FLOAT, T_STRING, T_KEYWORD:
+	default:
+		return ""
+	}
+	return did_you_mean(t.Lexeme, statement_keywords)
+}
+
+// parse_expression_statement finishes a statement that starts with expr,
+// which is setting an attribute if an '=' follows.
+func (p *parser) parse_expression_statement(expr Expression) (Statement, error) {
+	if p.peek().T != T_EQ {
+		return ExpressionStatement{expr, expr.span()}, nil
+	}
+	eq := p.read()
+	get, ok := expr.(GetAttrExpression)
+	if !ok {
+		err := syntax_errorf(eq.Span, "cannot assign to '%s'", expression_source(expr))
+		err.Hint = "only attributes, like 'obj.name', can be set"
+		return nil, err
+	}
+	value, err := p.parse_bools()
+	if err != nil {
+		return nil, wrap_error(err, eq.Span, "error parsing value for '%s'", expression_source(expr))
+	}
+	return SetAttrStatement{get.Object, get.Name, value, get.span().join(value.span())}, nil
+}
+
 func (p *parser) parse_keyword() (Statement, error) {
 	kwd := p.read()
 	switch kwd.Lexeme {
 	case "print":
+		exprs, err := p.parse_expression_list()
+		if err != nil {
+			return nil, err
+		}
+		return PrintStatment{exprs, p.span_from(kwd.Span)}, nil
+	case "write":
+		exprs, err := p.parse_expression_list()
+		if err != nil {
+			return nil, err
+		}
+		return WriteStatement{exprs, p.span_from(kwd.Span)}, nil
+	case "import":
+		t := p.peek()
+		err := p.consume(T_STRING)
+		if err != nil {
+			return nil, wrap_error(err, kwd.Span, "import wants a path")
+		}
+		return ImportStatement{t.Lexeme, p.span_from(kwd.Span)}, nil
+	case "assert":
 		expr, err := p.parse_bools()
 		if err != nil {
 			return nil, err
 		}
-		return PrintStatment{expr}, nil
+		var msg Expression
+		if p.peek().T == T_COMMA {
+			p.read()
+			msg, err = p.parse_bools()
+			if err != nil {
+				return nil, wrap_error(err, kwd.Span, "error parsing assert message")
+			}
+		}
+		return AssertStatement{expr, msg, p.span_from(kwd.Span)}, nil
 	default:
-		return nil, fmt.Errorf("do not recognise keyword '%v'", kwd.Lexeme)
+		err := syntax_errorf(kwd.Span, "do not recognise keyword '%v'", kwd.Lexeme)
+		err.Hint = did_you_mean(kwd.Lexeme, statement_keywords)
+		return nil, err
+	}
+}
+
+// parse_test parses a test block. Being a block it is not followed by a
+// semicolon.
+func (p *parser) parse_test() (Statement, error) {
+	kwd := p.read()
+	name := p.peek()
+	err := p.consume(T_STRING)
+	if err != nil {
+		return nil, wrap_error(err, kwd.Span, "test wants a name")
+	}
+	open := p.peek()
+	err = p.consume(T_LBRACE)
+	if err != nil {
+		return nil, wrap_error(err, name.Span, "error parsing test '%s'", name.Lexeme)
+	}
+
+	var body []Statement
+	for {
+		if p.gave_up() {
+			break
+		}
+		if p.curr >= len(p.tokens) {
+			err := syntax_errorf(p.eof_span(), "error parsing test '%s'. EOF", name.Lexeme)
+			err.Label = "expected '}'"
+			err.Related = []Label{{open.Span, "to close this '{'"}}
+			return nil, err
+		}
+		if p.peek().T == T_RBRACE {
+			p.read()
+			break
+		}
+		start := p.curr
+		stmt, err := p.parse_statement()
+		if err != nil {
+			p.recover_from(wrap_error(err, name.Span, "error parsing test '%s'", name.Lexeme), start)
+			continue
+		}
+		if _, ok := stmt.(TestStatement); ok {
+			p.errors = append(p.errors, &SyntaxError{
+				Span: stmt.span(),
+				Msg:  fmt.Sprintf("error parsing test '%s'. tests cannot be nested", name.Lexeme),
+			})
+			continue
+		}
+		body = append(body, stmt)
 	}
+
+	return TestStatement{name.Lexeme, body, p.span_from(kwd.Span)}, nil
 }
 
-func (p *parser) parse_bools() (Statement, error) {
+func (p *parser) parse_expression_list() ([]Expression, error) {
+	var exprs []Expression
+	for {
+		expr, err := p.parse_bools()
+		if err != nil {
+			return exprs, err
+		}
+		exprs = append(exprs, expr)
+		if p.peek().T != T_COMMA {
+			return exprs, nil
+		}
+		p.read()
+	}
+}
+
+func (p *parser) parse_bools() (Expression, error) {
 	expr, err := p.parse_expression()
 	if err != nil {
 		return expr, err
@@ -102,15 +478,15 @@ func (p *parser) parse_bools() (Statement, error) {
 		op := op_token_to_binary_op(op_token.T)
 		r, err := p.parse_expression()
 		if err != nil {
-			return r, nil
+			return nil, err
 		}
-		expr = BinaryExpression{op, expr, r}
+		expr = BinaryExpression{op, expr, r, expr.span().join(r.span())}
 	}
 
 	return expr, nil
 }
 
-func (p *parser) parse_expression() (Statement, error) {
+func (p *parser) parse_expression() (Expression, error) {
 	expr, err := p.parse_expression2()
 	if err != nil {
 		return expr, err
@@ -120,54 +496,130 @@ func (p *parser) parse_expression() (Statement, error) {
 		op := op_token_to_binary_op(op_token.T)
 		r, err := p.parse_expression2()
 		if err != nil {
-			return r, nil
+			return nil, err
 		}
-		expr = BinaryExpression{op, expr, r}
+		expr = BinaryExpression{op, expr, r, expr.span().join(r.span())}
 	}
 
 	return expr, nil
 }
 
-func (p *parser) parse_expression2() (Statement, error) {
-	expr, err := p.parse_literal()
+func (p *parser) parse_expression2() (Expression, error) {
+	expr, err := p.parse_unary()
 	if err != nil {
 		return expr, err
 	}
 	for p.peek().T == T_MULT || p.peek().T == T_DIV {
 		op_token := p.read()
 		op := op_token_to_binary_op(op_token.T)
-		r, err := p.parse_literal()
+		r, err := p.parse_unary()
+		if err != nil {
+			return nil, err
+		}
+		expr = BinaryExpression{op, expr, r, expr.span().join(r.span())}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) parse_unary() (Expression, error) {
+	if p.peek().T == T_MINUS {
+		op := p.read()
+		expr, err := p.parse_unary()
+		if err != nil {
+			return expr, err
+		}
+		return UnaryExpression{UO_NEGATE, expr, op.join(expr.span())}, nil
+	}
+	return p.parse_call()
+}
+
+func (p *parser) parse_call() (Expression, error) {
+	expr, err := p.parse_literal()
+	if err != nil {
+		return expr, err
+	}
+	for p.peek().T == T_LPAREN || p.peek().T == T_DOT {
+		t := p.read()
+		if t.T == T_DOT {
+			name := p.peek()
+			err := p.consume(T_KEYWORD)
+			if err != nil {
+				return nil, wrap_error(err, t.Span, "error parsing attribute name")
+			}
+			expr = GetAttrExpression{expr, name.Lexeme, expr.span().join(name.Span)}
+			continue
+		}
+		args, err := p.parse_arguments(t)
 		if err != nil {
-			return r, nil
+			return nil, wrap_error(err, expr.span(), "error parsing call arguments")
 		}
-		expr = BinaryExpression{op, expr, r}
+		expr = CallExpression{expr, args, p.span_from(expr.span())}
 	}
 
 	return expr, nil
 }
 
-func (p *parser) parse_literal() (Statement, error) {
+// parse_arguments parses the arguments of a call after its opening bracket.
+func (p *parser) parse_arguments(open Token) ([]Expression, error) {
+	var args []Expression
+	if p.peek().T == T_RPAREN {
+		p.read()
+		return args, nil
+	}
+	for {
+		arg, err := p.parse_bools()
+		if err != nil {
+			return args, err
+		}
+		args = append(args, arg)
+		if p.peek().T == T_COMMA {
+			p.read()
+			continue
+		}
+		return args, p.consume_closing(T_RPAREN, open)
+	}
+}
+
+func (p *parser) parse_literal() (Expression, error) {
+	if p.curr >= len(p.tokens) {
+		return nil, syntax_errorf(p.eof_span(), "could not parse literal. EOF")
+	}
 	t := p.read()
 	switch t.T {
 	case T_INT:
 		d, err := strconv.ParseInt(t.Lexeme, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf("could not parse literal '%s'. %s", t.Lexeme, err)
+			return nil, syntax_errorf(t.Span, "could not parse literal '%s'. %s", t.Lexeme, err)
+		}
+		return LiteralExpression{IntValue(d), t.Span}, nil
+	case T_FLOAT:
+		f, err := strconv.ParseFloat(t.Lexeme, 64)
+		if err != nil {
+			return nil, syntax_errorf(t.Span, "could not parse literal '%s'. %s", t.Lexeme, err)
 		}
-		return LiteralExpression{IntValue(d)}, nil
+		return LiteralExpression{FloatValue(f), t.Span}, nil
 	case T_KEYWORD:
 		switch t.Lexeme {
 		case "true":
-			return LiteralExpression{TrueValue(true)}, nil
+			return LiteralExpression{TrueValue(true), t.Span}, nil
 		case "false":
-			return LiteralExpression{FalseValue(false)}, nil
+			return LiteralExpression{FalseValue(false), t.Span}, nil
+		case "nil":
+			return LiteralExpression{NilValue{}, t.Span}, nil
 		default:
-			return nil, fmt.Errorf("could not parse literal as keyword '%#v'", t)
+			return VariableExpression{t.Lexeme, t.Span}, nil
 		}
 	case T_STRING:
-		return LiteralExpression{StringValue(t.Lexeme)}, nil
+		return LiteralExpression{StringValue(t.Lexeme), t.Span}, nil
+	case T_LPAREN:
+		expr, err := p.parse_bools()
+		if err != nil {
+			return expr, err
+		}
+		return expr, p.consume_closing(T_RPAREN, t)
 	default:
-		return nil, fmt.Errorf("could not parse literal '%#v'", t)
+		return nil, syntax_errorf(t.Span, "could not parse literal '%s'", t.Lexeme)
 	}
 }
 
@@ -190,16 +642,72 @@ func op_token_to_binary_op(t TokenType) BinaryOperator {
 
 func (p *parser) consume(T TokenType) error {
 	if p.curr >= len(p.tokens) {
-		return fmt.Errorf("error consuming. EOF")
+		err := syntax_errorf(p.eof_span(), "error consuming. wanted '%v' but got EOF", T)
+		err.Label = "expected " + token_description(T)
+		return err
 	}
 	t := p.tokens[p.curr]
 	if t.T != T {
-		return fmt.Errorf("error consuming. wanted '%v' but got '%v'", T, t.T)
+		err := syntax_errorf(t.Span, "error consuming. wanted '%v' but got '%v'", T, t.T)
+		err.Label = "expected " + token_description(T)
+		return err
 	}
 	p.curr++
 	return nil
 }
 
+// consume_closing consumes the bracket that closes open, pointing back at
+// open if it is missing.
+func (p *parser) consume_closing(T TokenType, open Token) error {
+	err := p.consume(T)
+	if se, ok := err.(*SyntaxError); ok {
+		se.Related = append(se.Related, Label{open.Span, fmt.Sprintf("to close this '%s'", open.Lexeme)})
+	}
+	return err
+}
+
+// token_description names a type of token the way it looks in source.
+func token_description(t TokenType) string {
+	switch t {
+	case T_INT:
+		return "an int"
+	case T_FLOAT:
+		return "a float"
+	case T_STRING:
+		return "a string"
+	case T_KEYWORD:
+		return "a name"
+	case T_SEMI:
+		return "';'"
+	case T_MULT:
+		return "'*'"
+	case T_ADD:
+		return "'+'"
+	case T_DIV:
+		return "'/'"
+	case T_MINUS:
+		return "'-'"
+	case T_EQ:
+		return "'='"
+	case T_EQ_EQ:
+		return "'=='"
+	case T_LPAREN:
+		return "'('"
+	case T_RPAREN:
+		return "')'"
+	case T_COMMA:
+		return "','"
+	case T_DOT:
+		return "'.'"
+	case T_LBRACE:
+		return "'{'"
+	case T_RBRACE:
+		return "'}'"
+	default:
+		return t.String()
+	}
+}
+
 func (p *parser) peek() Token {
 	if p.curr >= len(p.tokens) {
 		return Token{}
@@ -212,3 +720,19 @@ func (p *parser) read() Token {
 	p.curr++
 	return t
 }
+
+// span_from returns the span from the start of s to the end of the last
+// token read.
+func (p *parser) span_from(s Span) Span {
+	return Span{s.Start, p.tokens[p.curr-1].End}
+}
+
+// eof_span is where the source ends, for errors about running out of
+// tokens.
+func (p *parser) eof_span() Span {
+	if len(p.tokens) == 0 {
+		return Span{}
+	}
+	end := p.tokens[len(p.tokens)-1].End
+	return Span{end, end}
+}
@@ -1,9 +1,14 @@
 package laks
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestParse(t *testing.T) {
@@ -20,47 +25,51 @@ func TestParse(t *testing.T) {
 		{
 			name: "literal",
 			in: []Token{
-				{T_INT, "44"},
-				{T_SEMI, ";"},
+				{T: T_INT, Lexeme: "44"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 			want: []Statement{
-				LiteralExpression{IntValue(int64(44))},
+				ExpressionStatement{Expr: LiteralExpression{Value: IntValue(int64(44))}},
 			},
 		},
 		{
 			name: "simple_add",
 			in: []Token{
-				{T_INT, "6"},
-				{T_ADD, "+"},
-				{T_INT, "7"},
-				{T_SEMI, ";"},
+				{T: T_INT, Lexeme: "6"},
+				{T: T_ADD, Lexeme: "+"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 			want: []Statement{
-				BinaryExpression{
-					BO_ADD,
-					LiteralExpression{IntValue(int64(6))},
-					LiteralExpression{IntValue(int64(7))},
+				ExpressionStatement{
+					Expr: BinaryExpression{
+						Op:    BO_ADD,
+						Left:  LiteralExpression{Value: IntValue(int64(6))},
+						Right: LiteralExpression{Value: IntValue(int64(7))},
+					},
 				},
 			},
 		},
 		{
 			name: "prec1",
 			in: []Token{
-				{T_INT, "6"},
-				{T_ADD, "+"},
-				{T_INT, "7"},
-				{T_MULT, "*"},
-				{T_INT, "9"},
-				{T_SEMI, ";"},
+				{T: T_INT, Lexeme: "6"},
+				{T: T_ADD, Lexeme: "+"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "9"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 			want: []Statement{
-				BinaryExpression{
-					BO_ADD,
-					LiteralExpression{IntValue(int64(6))},
-					BinaryExpression{
-						BO_MULT,
-						LiteralExpression{IntValue(int64(7))},
-						LiteralExpression{IntValue(int64(9))},
+				ExpressionStatement{
+					Expr: BinaryExpression{
+						Op:   BO_ADD,
+						Left: LiteralExpression{Value: IntValue(int64(6))},
+						Right: BinaryExpression{
+							Op:    BO_MULT,
+							Left:  LiteralExpression{Value: IntValue(int64(7))},
+							Right: LiteralExpression{Value: IntValue(int64(9))},
+						},
 					},
 				},
 			},
@@ -68,44 +77,230 @@ func TestParse(t *testing.T) {
 		{
 			name: "prec2",
 			in: []Token{
-				{T_INT, "6"},
-				{T_MULT, "*"},
-				{T_INT, "7"},
-				{T_ADD, "+"},
-				{T_INT, "9"},
-				{T_SEMI, ";"},
+				{T: T_INT, Lexeme: "6"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_ADD, Lexeme: "+"},
+				{T: T_INT, Lexeme: "9"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 			want: []Statement{
-				BinaryExpression{
-					BO_ADD,
-					BinaryExpression{
-						BO_MULT,
-						LiteralExpression{IntValue(int64(6))},
-						LiteralExpression{IntValue(int64(7))},
+				ExpressionStatement{
+					Expr: BinaryExpression{
+						Op: BO_ADD,
+						Left: BinaryExpression{
+							Op:    BO_MULT,
+							Left:  LiteralExpression{Value: IntValue(int64(6))},
+							Right: LiteralExpression{Value: IntValue(int64(7))},
+						},
+						Right: LiteralExpression{Value: IntValue(int64(9))},
 					},
-					LiteralExpression{IntValue(int64(9))},
 				},
 			},
 		},
 		{
 			name: "print something",
 			in: []Token{
-				{T_KEYWORD, "print"},
-				{T_INT, "7"},
-				{T_MULT, "*"},
-				{T_INT, "8"},
-				{T_SEMI, ";"},
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "8"},
+				{T: T_SEMI, Lexeme: ";"},
+			},
+			want: []Statement{
+				PrintStatment{
+					Exprs: []Expression{
+						BinaryExpression{
+							Op:    BO_MULT,
+							Left:  LiteralExpression{Value: IntValue(int64(7))},
+							Right: LiteralExpression{Value: IntValue(int64(8))},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "call",
+			in: []Token{
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_KEYWORD, Lexeme: "upper"},
+				{T: T_LPAREN, Lexeme: "("},
+				{T: T_STRING, Lexeme: "a"},
+				{T: T_ADD, Lexeme: "+"},
+				{T: T_STRING, Lexeme: "b"},
+				{T: T_COMMA, Lexeme: ","},
+				{T: T_KEYWORD, Lexeme: "x"},
+				{T: T_RPAREN, Lexeme: ")"},
+				{T: T_SEMI, Lexeme: ";"},
+			},
+			want: []Statement{
+				PrintStatment{
+					Exprs: []Expression{
+						CallExpression{
+							Callee: VariableExpression{Name: "upper"},
+							Args: []Expression{
+								BinaryExpression{
+									Op:    BO_ADD,
+									Left:  LiteralExpression{Value: StringValue("a")},
+									Right: LiteralExpression{Value: StringValue("b")},
+								},
+								VariableExpression{Name: "x"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "grouping",
+			in: []Token{
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_LPAREN, Lexeme: "("},
+				{T: T_INT, Lexeme: "1"},
+				{T: T_ADD, Lexeme: "+"},
+				{T: T_INT, Lexeme: "2"},
+				{T: T_RPAREN, Lexeme: ")"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "3"},
+				{T: T_SEMI, Lexeme: ";"},
+			},
+			want: []Statement{
+				PrintStatment{
+					Exprs: []Expression{
+						BinaryExpression{
+							Op: BO_MULT,
+							Left: BinaryExpression{
+								Op:    BO_ADD,
+								Left:  LiteralExpression{Value: IntValue(int64(1))},
+								Right: LiteralExpression{Value: IntValue(int64(2))},
+							},
+							Right: LiteralExpression{Value: IntValue(int64(3))},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "negate attribute",
+			in: []Token{
+				{T: T_MINUS, Lexeme: "-"},
+				{T: T_KEYWORD, Lexeme: "math"},
+				{T: T_DOT, Lexeme: "."},
+				{T: T_KEYWORD, Lexeme: "pi"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_FLOAT, Lexeme: "2.5"},
+				{T: T_SEMI, Lexeme: ";"},
+			},
+			want: []Statement{
+				ExpressionStatement{
+					Expr: BinaryExpression{
+						Op: BO_MULT,
+						Left: UnaryExpression{
+							Op:   UO_NEGATE,
+							Expr: GetAttrExpression{Object: VariableExpression{Name: "math"}, Name: "pi"},
+						},
+						Right: LiteralExpression{Value: FloatValue(2.5)},
+					},
+				},
+			},
+		},
+		{
+			name: "print and write many",
+			in: []Token{
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_INT, Lexeme: "1"},
+				{T: T_COMMA, Lexeme: ","},
+				{T: T_STRING, Lexeme: "a"},
+				{T: T_SEMI, Lexeme: ";"},
+				{T: T_KEYWORD, Lexeme: "write"},
+				{T: T_INT, Lexeme: "2"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 			want: []Statement{
 				PrintStatment{
-					BinaryExpression{
-						BO_MULT,
-						LiteralExpression{IntValue(int64(7))},
-						LiteralExpression{IntValue(int64(8))},
+					Exprs: []Expression{
+						LiteralExpression{Value: IntValue(int64(1))},
+						LiteralExpression{Value: StringValue("a")},
+					},
+				},
+				WriteStatement{
+					Exprs: []Expression{
+						LiteralExpression{Value: IntValue(int64(2))},
 					},
 				},
 			},
 		},
+		{
+			name: "expression statements",
+			in: []Token{
+				{T: T_STRING, Lexeme: "a"},
+				{T: T_EQ_EQ, Lexeme: "=="},
+				{T: T_STRING, Lexeme: "a"},
+				{T: T_SEMI, Lexeme: ";"},
+				{T: T_KEYWORD, Lexeme: "foo"},
+				{T: T_LPAREN, Lexeme: "("},
+				{T: T_RPAREN, Lexeme: ")"},
+				{T: T_SEMI, Lexeme: ";"},
+			},
+			want: []Statement{
+				ExpressionStatement{
+					Expr: BinaryExpression{
+						Op:    BO_EQ,
+						Left:  LiteralExpression{Value: StringValue("a")},
+						Right: LiteralExpression{Value: StringValue("a")},
+					},
+				},
+				ExpressionStatement{
+					Expr: CallExpression{Callee: VariableExpression{Name: "foo"}, Args: nil},
+				},
+			},
+		},
+		{
+			name: "test and assert",
+			in: []Token{
+				{T: T_KEYWORD, Lexeme: "test"},
+				{T: T_STRING, Lexeme: "maths"},
+				{T: T_LBRACE, Lexeme: "{"},
+				{T: T_KEYWORD, Lexeme: "assert"},
+				{T: T_INT, Lexeme: "1"},
+				{T: T_EQ_EQ, Lexeme: "=="},
+				{T: T_INT, Lexeme: "1"},
+				{T: T_COMMA, Lexeme: ","},
+				{T: T_STRING, Lexeme: "one"},
+				{T: T_SEMI, Lexeme: ";"},
+				{T: T_KEYWORD, Lexeme: "assert"},
+				{T: T_KEYWORD, Lexeme: "true"},
+				{T: T_SEMI, Lexeme: ";"},
+				{T: T_RBRACE, Lexeme: "}"},
+			},
+			want: []Statement{
+				TestStatement{
+					Name: "maths",
+					Body: []Statement{
+						AssertStatement{
+							Expr: BinaryExpression{
+								Op:    BO_EQ,
+								Left:  LiteralExpression{Value: IntValue(int64(1))},
+								Right: LiteralExpression{Value: IntValue(int64(1))},
+							},
+							Message: LiteralExpression{Value: StringValue("one")},
+						},
+						AssertStatement{Expr: LiteralExpression{Value: TrueValue(true)}, Message: nil},
+					},
+				},
+			},
+		},
+		{
+			name: "import",
+			in: []Token{
+				{T: T_KEYWORD, Lexeme: "import"},
+				{T: T_STRING, Lexeme: "lib.lak"},
+				{T: T_SEMI, Lexeme: ";"},
+			},
+			want: []Statement{
+				ImportStatement{Path: "lib.lak"},
+			},
+		},
 	}
 
 	for _, tst := range tests {
@@ -114,7 +309,7 @@ func TestParse(t *testing.T) {
 			if err != nil {
 				tt.Fatalf("%s", err.Error())
 			}
-			if diff := cmp.Diff(tst.want, got); diff != "" {
+			if diff := cmp.Diff(tst.want, got, cmpopts.IgnoreTypes(Span{})); diff != "" {
 				tt.Errorf("Mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -124,11 +319,165 @@ func TestParse(t *testing.T) {
 
 func TestErorrs(t *testing.T) {
 	in := []Token{
-		{T_KEYWORD, "print"},
-		{T_STRING, "foobar!"},
+		{T: T_KEYWORD, Lexeme: "print"},
+		{T: T_STRING, Lexeme: "foobar!"},
 	}
 	_, err := Parse(in)
 	if err == nil {
 		t.Fatalf("wanted error")
 	}
 }
+
+func TestParseSpans(t *testing.T) {
+	tokens, err := TokeniseFile("a.lak", []byte("print 1 + f(2);\nassert -x.y;"))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	stmts, err := Parse(tokens)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	print := stmts[0].(PrintStatment)
+	add := print.Exprs[0].(BinaryExpression)
+	assert := stmts[1].(AssertStatement)
+	tests := []struct {
+		node Span
+		want string
+	}{
+		{print.Span, "1:1-1:15"},
+		{add.Span, "1:7-1:15"},
+		{add.Right.span(), "1:11-1:15"},
+		{assert.Span, "2:1-2:12"},
+		{assert.Expr.span(), "2:8-2:12"},
+		{assert.Expr.(UnaryExpression).Expr.span(), "2:9-2:12"},
+	}
+
+	for _, tst := range tests {
+		s := tst.node
+		got := fmt.Sprintf("%d:%d-%d:%d", s.Start.Line, s.Start.Col, s.End.Line, s.End.Col)
+		if got != tst.want {
+			t.Errorf("wanted span %s but got %s", tst.want, got)
+		}
+		if s.Start.File != "a.lak" {
+			t.Errorf("wanted file a.lak but got %q", s.Start.File)
+		}
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"print 1\nprint 2;", "a.lak:2:1: error parsing statement. error consuming. wanted 'T_SEMI' but got 'T_KEYWORD'"},
+		{"prnt 1;", "a.lak:1:1: error parsing statement. do not recognise keyword 'prnt'. did you mean 'print'?"},
+		{"foo 1;", "a.lak:1:5: error parsing statement. error consuming. wanted 'T_SEMI' but got 'T_INT'"},
+		{"print (1", "a.lak:1:9: error parsing statement. error consuming. wanted 'T_RPAREN' but got EOF"},
+		{"print 1 =", "a.lak:1:9: error parsing statement. error consuming. wanted 'T_SEMI' but got 'T_EQ'"},
+		{"import 1;", "a.lak:1:8: error parsing statement. import wants a path. error consuming. wanted 'T_STRING' but got 'T_INT'"},
+		{"x = 1;", "a.lak:1:3: error parsing statement. cannot assign to 'x'. only attributes, like 'obj.name', can be set"},
+	}
+
+	for _, tst := range tests {
+		tokens, err := TokeniseFile("a.lak", []byte(tst.in))
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		_, err = Parse(tokens)
+		if err == nil || err.Error() != tst.want {
+			t.Errorf("%q: wanted error %q but got %v", tst.in, tst.want, err)
+		}
+	}
+}
+
+func TestParseReportsEveryError(t *testing.T) {
+	src := `print 1 +;
+print "fine";
+write (2;
+assert 1 == ;
+prnt 2;
+test "t" {
+	print * 3;
+	print 4;
+}
+print 5 print 6;
+`
+	tokens, err := Tokenise([]byte(src))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	stmts, err := Parse(tokens)
+
+	var errs SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("wanted SyntaxErrors but got %#v", err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Span.Start.String())
+	}
+	want := []string{"1:10", "3:9", "4:13", "5:1", "7:8", "10:9"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s\n%v", diff, err)
+	}
+
+	// The statements around the errors are still parsed.
+	if len(stmts) != 3 {
+		t.Errorf("wanted 3 statements but got %d: %v", len(stmts), stmts)
+	}
+}
+
+func TestParseFailedRightHandSide(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"print 1 + ;", "1:11: error parsing statement. could not parse literal ';'"},
+		{"print 2 * ;", "1:11: error parsing statement. could not parse literal ';'"},
+		{"print 3 == ;", "1:12: error parsing statement. could not parse literal ';'"},
+	}
+
+	for _, tst := range tests {
+		tokens, err := Tokenise([]byte(tst.in))
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		_, err = Parse(tokens)
+		if err == nil || err.Error() != tst.want {
+			t.Errorf("%q: wanted error %q but got %v", tst.in, tst.want, err)
+		}
+	}
+}
+
+func TestParseMaxErrors(t *testing.T) {
+	tokens, err := Tokenise([]byte(strings.Repeat("print +;\n", 20)))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	_, err = Parse(tokens)
+	var errs SyntaxErrors
+	if !errors.As(err, &errs) || len(errs) != DefaultMaxErrors+1 {
+		t.Fatalf("wanted %d errors and a note but got %v", DefaultMaxErrors, err)
+	}
+	if errs[len(errs)-1].Msg != "too many errors" {
+		t.Errorf("wanted a note that there were too many errors but got %v", errs[len(errs)-1])
+	}
+
+	_, err = parse(tokens, 0)
+	if !errors.As(err, &errs) || len(errs) != 20 {
+		t.Errorf("wanted all 20 errors without a limit but got %d", len(errs))
+	}
+
+	_, err = CompileBytes([]byte(strings.Repeat("print +;\n", 20)), WithMaxErrors(3))
+	if !errors.As(err, &errs) || len(errs) != 4 {
+		t.Errorf("wanted 3 errors and a note but got %d", len(errs))
+	}
+
+	modules := fstest.MapFS{"lib.lak": {Data: []byte(strings.Repeat("print +;\n", 20))}}
+	_, err = CompileBytes([]byte(`import "lib.lak";`), WithModules(modules), WithMaxErrors(0))
+	if !errors.As(err, &errs) || len(errs) != 20 {
+		t.Errorf("wanted all 20 errors in an imported module without a limit but got %d", len(errs))
+	}
+}
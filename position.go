@@ -0,0 +1,43 @@
+package laks
+
+import (
+	"fmt"
+)
+
+// Pos is a place in the source. Lines and columns count from 1, columns in
+// bytes. Offset is the byte offset from the start of the source.
+type Pos struct {
+	File   string
+	Line   int
+	Col    int
+	Offset int
+}
+
+// String renders the position as file:line:col, leaving out the file when
+// there is none.
+func (p Pos) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// IsValid reports whether the position refers to somewhere in the source.
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+// Span is the part of the source from Start up to, but not including, End.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+func (s Span) span() Span {
+	return s
+}
+
+// join returns a span covering both s and other.
+func (s Span) join(other Span) Span {
+	return Span{s.Start, other.End}
+}
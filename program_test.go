@@ -1,10 +1,8 @@
 package laks
 
 import (
-	"bufio"
 	"bytes"
 	"embed"
-	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -30,16 +28,7 @@ func TestPrograms(t *testing.T) {
 				tt.Fatalf("could not read file %s: %v", program.Name(), err)
 			}
 
-			var expectedBuf strings.Builder
-			scanner := bufio.NewScanner(bytes.NewReader(b))
-			for scanner.Scan() {
-				line := scanner.Text()
-				if strings.HasPrefix(line, "# ") {
-					expected := strings.TrimPrefix(line, "# ")
-					expectedBuf.WriteString(expected)
-					expectedBuf.WriteByte('\n')
-				}
-			}
+			expected := ExpectedOutput(b)
 
 			outputBuf := &bytes.Buffer{}
 			err = RunBytes(b, outputBuf)
@@ -47,8 +36,8 @@ func TestPrograms(t *testing.T) {
 				tt.Fatalf("could not run program %s: %v", program.Name(), err)
 			}
 
-			if cmp.Diff(expectedBuf.String(), outputBuf.String()) != "" {
-				tt.Errorf("output mismatch for program %s:\n%s", program.Name(), cmp.Diff(expectedBuf.String(), outputBuf.String()))
+			if cmp.Diff(expected, outputBuf.String()) != "" {
+				tt.Errorf("output mismatch for program %s:\n%s", program.Name(), cmp.Diff(expected, outputBuf.String()))
 			}
 		})
 	}
@@ -3,39 +3,158 @@ package laks
 import (
 	// "fmt"
 	"io"
+	"io/fs"
+	"strings"
 )
 
-func RunBytes(b []byte, w io.Writer) error {
-	tokens, err := Tokenise(b)
+type config struct {
+	modules     fs.FS
+	input       io.Reader
+	file_access bool
+	file_root   string
+	source_name string
+	max_errors  int
+	args        []string
+	natives     []*NativeFunction
+}
+
+func new_config(opts []Option) config {
+	c := config{input: strings.NewReader(""), max_errors: DefaultMaxErrors}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// Option configures how a program is run.
+type Option func(*config)
+
+// WithModules makes imports resolve against fsys. Without it any import
+// fails, so the host decides whether scripts can load other source at all.
+func WithModules(fsys fs.FS) Option {
+	return func(c *config) {
+		c.modules = fsys
+	}
+}
+
+// WithInput sets where scripts read input from. Without it scripts see an
+// empty input. A *bufio.Reader is read from as it is, so a host that also
+// reads from it does not lose input to a second buffer.
+func WithInput(r io.Reader) Option {
+	return func(c *config) {
+		c.input = r
+	}
+}
+
+// WithFileAccess enables the natives that read and write files, which are
+// disabled by default. If root is not empty every path is resolved inside
+// root and scripts cannot reach anything outside it.
+func WithFileAccess(root string) Option {
+	return func(c *config) {
+		c.file_access = true
+		c.file_root = root
+	}
+}
+
+// WithSourceName names the file the source came from, for positions in
+// error messages.
+func WithSourceName(name string) Option {
+	return func(c *config) {
+		c.source_name = name
+	}
+}
+
+// WithMaxErrors sets how many syntax errors are reported before giving up.
+// Zero means there is no limit.
+func WithMaxErrors(n int) Option {
+	return func(c *config) {
+		c.max_errors = n
+	}
+}
+
+// WithArgs sets the arguments scripts see in the args list. Without it the
+// list is empty.
+func WithArgs(args ...string) Option {
+	return func(c *config) {
+		c.args = args
+	}
+}
+
+// WithNatives makes Go functions available to scripts as globals, as
+// Interpreter.RegisterNative does.
+func WithNatives(fns ...*NativeFunction) Option {
+	return func(c *config) {
+		c.natives = append(c.natives, fns...)
+	}
+}
+
+// RunBytes compiles and runs source, writing its output to w. Any error is
+// a SyntaxErrors, *CompileError or *RuntimeError depending on the stage that
+// failed, or an *ExitError if the script called exit.
+func RunBytes(b []byte, w io.Writer, opts ...Option) error {
+	p, err := BuildProgram(b, opts...)
 	if err != nil {
 		return err
 	}
 
-	// fmt.Printf("\t%v\n", tokens)
+	// for _, b := range p.Code {
+	// 	fmt.Printf("%x\n", b)
+	// }
+	// fmt.Println()
 
-	exprs, err := Parse(tokens)
+	err = RunProgram(p, w, opts...)
 	if err != nil {
 		return err
 	}
 
+	return nil
+}
+
+// CompileBytes takes source all the way to bytecode without running it.
+// Like Compile, the bytecode runs on its own.
+func CompileBytes(b []byte, opts ...Option) ([]byte, error) {
+	stmts, err := parse_program(b, new_config(opts))
+	if err != nil {
+		return nil, err
+	}
+	return Compile(stmts)
+}
+
+// BuildProgram takes source all the way to a program with debug info,
+// ready to run or save.
+func BuildProgram(b []byte, opts ...Option) (*Program, error) {
+	c := new_config(opts)
+	exprs, err := parse_program(b, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// for _, e := range exprs {
 	// 	fmt.Printf("\t%v\n", e)
 	// }
 
-	bytecode, err := Compile(exprs)
+	p, err := CompileProgram(exprs)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	p.Debug.Source = c.source_name
+	return p, nil
+}
 
-	// for _, b := range bytecode {
-	// 	fmt.Printf("%x\n", b)
-	// }
-	// fmt.Println()
+// parse_program takes source through to statements with their imports
+// resolved.
+func parse_program(b []byte, c config) ([]Statement, error) {
+	tokens, err := TokeniseFile(c.source_name, b)
+	if err != nil {
+		return nil, err
+	}
+
+	// fmt.Printf("\t%v\n", tokens)
 
-	err = Run(bytecode, w)
+	stmts, err := parse(tokens, c.max_errors)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return resolve_imports(stmts, c.modules, c.source_name, c.max_errors)
 }
@@ -0,0 +1,52 @@
+package laks
+
+import (
+	"fmt"
+	"slices"
+)
+
+// did_you_mean returns a hint naming the candidate closest to name, or ""
+// if none is close enough to be a likely misspelling.
+func did_you_mean(name string, candidates []string) string {
+	best, best_dist := "", max(1, len(name)/3)+1
+	for _, c := range slices.Sorted(slices.Values(candidates)) {
+		if c == name {
+			continue
+		}
+		if d := edit_distance(name, c); d < best_dist {
+			best, best_dist = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("did you mean '%s'?", best)
+}
+
+// edit_distance is the number of single character insertions, deletions,
+// substitutions and swaps of neighbouring characters that turn a into b.
+func edit_distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// rows[i][j] is the distance between ra[:i] and rb[:j].
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
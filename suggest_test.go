@@ -0,0 +1,29 @@
+package laks
+
+import "testing"
+
+func TestDidYouMean(t *testing.T) {
+	var tests = []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		{"prnt", statement_keywords, "did you mean 'print'?"},
+		{"pirnt", statement_keywords, "did you mean 'print'?"},
+		{"imprt", statement_keywords, "did you mean 'import'?"},
+		{"tset", statement_keywords, "did you mean 'test'?"},
+		{"x", statement_keywords, ""},
+		{"banana", statement_keywords, ""},
+		{"print", statement_keywords, ""},
+		{"lne", []string{"len", "lines"}, "did you mean 'len'?"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			got := did_you_mean(tst.name, tst.candidates)
+			if got != tst.want {
+				tt.Errorf("wanted %q but got %q", tst.want, got)
+			}
+		})
+	}
+}
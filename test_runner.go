@@ -0,0 +1,47 @@
+package laks
+
+import (
+	"bytes"
+)
+
+// TestResult is the outcome of running one test block.
+type TestResult struct {
+	Name   string
+	Output string
+	Err    error // nil when the test passed
+}
+
+// RunTests runs every test block in b. Each test gets a fresh interpreter
+// which first runs the statements outside of any test block, so tests cannot
+// see each other's effects. An error is only returned if the program as a
+// whole could not be read; failing tests are reported in the results.
+func RunTests(b []byte, opts ...Option) ([]TestResult, error) {
+	stmts, err := parse_program(b, new_config(opts))
+	if err != nil {
+		return nil, err
+	}
+	p, err := CompileProgram(stmts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []TestResult
+	for _, test := range p.Functions[1:] {
+		var out bytes.Buffer
+		err := run_test(p, test, &out, opts)
+		results = append(results, TestResult{test.Name, out.String(), err})
+	}
+	return results, nil
+}
+
+// run_test runs the main program and then the test starting at
+// test.Offset.
+func run_test(p *Program, test FunctionInfo, out *bytes.Buffer, opts []Option) error {
+	bi := new_interpreter(nil, out, new_config(opts))
+	bi.load(p)
+	if err := bi.run(); err != nil {
+		return err
+	}
+	bi.ip = test.Offset
+	return bi.run()
+}
@@ -0,0 +1,64 @@
+package laks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunTests(t *testing.T) {
+	src := `
+print "setup";
+
+test "passes" {
+	assert 1 + 1 == 2;
+	assert contains("haystack", "st"), "finds the needle";
+	print "in passes";
+}
+
+test "fails comparison" {
+	assert upper("a") == "b";
+	print "not reached";
+}
+
+test "fails with message" {
+	assert len(""), "wanted " + "something";
+}
+
+print "more setup";
+`
+	results, err := RunTests([]byte(src))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	type result struct {
+		Name   string
+		Output string
+		Err    string
+	}
+	var got []result
+	for _, r := range results {
+		var msg string
+		if r.Err != nil {
+			msg = r.Err.Error()
+		}
+		got = append(got, result{r.Name, r.Output, msg})
+	}
+
+	want := []result{
+		{"passes", "setup\nmore setup\nin passes\n", ""},
+		{"fails comparison", "setup\nmore setup\n", `11:2: assertion failed: upper("a") == "b". left is "A", right is "b"`},
+		{"fails with message", "setup\nmore setup\n", "16:2: assertion failed: wanted something"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunTestsSyntaxError(t *testing.T) {
+	_, err := RunTests([]byte(`test "unclosed" { print 1;`))
+	if err == nil {
+		t.Fatalf("wanted error")
+	}
+}
@@ -1,7 +1,7 @@
 package laks
 
 import (
-	"fmt"
+	"errors"
 	"slices"
 	"strings"
 )
@@ -20,21 +20,40 @@ const (
 	T_EQ
 	T_EQ_EQ
 	T_STRING
+	T_LPAREN
+	T_RPAREN
+	T_COMMA
+	T_FLOAT
+	T_DOT
+	T_LBRACE
+	T_RBRACE
 )
 
 type Token struct {
 	T      TokenType
 	Lexeme string
+	Span
 }
 
 type tokeniser struct {
 	src     []byte
+	file    string
 	current int
+	line    int
+	col     int
+	start   Pos // where the token being read began
 	tokens  []Token
 }
 
+// Tokenise splits src into tokens. Positions have no file name; use
+// TokeniseFile to give them one.
 func Tokenise(src []byte) ([]Token, error) {
-	var t = tokeniser{src: src}
+	return TokeniseFile("", src)
+}
+
+// TokeniseFile splits src into tokens, with positions naming file.
+func TokeniseFile(file string, src []byte) ([]Token, error) {
+	var t = tokeniser{src: src, file: file, line: 1, col: 1}
 	err := t.tokenise()
 	return t.tokens, err
 }
@@ -42,6 +61,7 @@ func Tokenise(src []byte) ([]Token, error) {
 func (t *tokeniser) tokenise() error {
 	for t.current < len(t.src) {
 		r := t.peek()
+		t.start = t.pos()
 
 		if r < '!' {
 			t.read()
@@ -54,22 +74,55 @@ func (t *tokeniser) tokenise() error {
 			t.tokenise_operator()
 		} else if r == ';' {
 			t.read()
-			t.tokens = append(t.tokens, Token{T_SEMI, string(r)})
+			t.emit(T_SEMI, string(r))
+		} else if r == '(' {
+			t.read()
+			t.emit(T_LPAREN, string(r))
+		} else if r == ')' {
+			t.read()
+			t.emit(T_RPAREN, string(r))
+		} else if r == ',' {
+			t.read()
+			t.emit(T_COMMA, string(r))
+		} else if r == '{' {
+			t.read()
+			t.emit(T_LBRACE, string(r))
+		} else if r == '}' {
+			t.read()
+			t.emit(T_RBRACE, string(r))
+		} else if r == '.' {
+			t.read()
+			t.emit(T_DOT, string(r))
 		} else if r >= 'a' && r <= 'z' {
 			t.tokenise_keyword()
 		} else if r == '#' {
 			t.eat_comment()
 		} else if r == '"' {
-			t.tokenise_string()
+			err := t.tokenise_string()
+			if err != nil {
+				return err
+			}
 		} else {
-			return fmt.Errorf("cannot tokenise '%c'", r)
+			return syntax_errorf(Span{t.start, t.start}, "cannot tokenise '%c'", r)
 		}
 	}
 
 	return nil
 }
 
-func (t *tokeniser) tokenise_string() {
+// ErrUnterminatedString is returned, wrapped with the position of the
+// string, when the source ends inside a string.
+var ErrUnterminatedString = errors.New("got to end of file while reading string")
+
+func (t *tokeniser) emit(tt TokenType, lexeme string) {
+	t.tokens = append(t.tokens, Token{tt, lexeme, Span{t.start, t.pos()}})
+}
+
+func (t *tokeniser) pos() Pos {
+	return Pos{t.file, t.line, t.col, t.current}
+}
+
+func (t *tokeniser) tokenise_string() error {
 	t.read() // The opening quotes
 
 	var sb strings.Builder
@@ -77,13 +130,13 @@ func (t *tokeniser) tokenise_string() {
 	for t.current < len(t.src) {
 		r := t.read()
 		if r == '"' {
-			t.tokens = append(t.tokens, Token{T_STRING, sb.String()})
-			return
+			t.emit(T_STRING, sb.String())
+			return nil
 		}
 		sb.WriteByte(r)
 	}
 
-	panic("got to end of file while reading string")
+	return &SyntaxError{Span: Span{t.start, t.pos()}, Msg: ErrUnterminatedString.Error(), Err: ErrUnterminatedString}
 }
 
 func (t *tokeniser) eat_comment() {
@@ -104,59 +157,78 @@ func (t *tokeniser) tokenise_keyword() {
 	for t.current < len(t.src) {
 		r := t.peek()
 
-		if r >= 'a' && r <= 'z' {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
 			sb.WriteByte(t.read())
 		} else {
 			break
 		}
 	}
 
-	t.tokens = append(t.tokens, Token{T_KEYWORD, sb.String()})
+	t.emit(T_KEYWORD, sb.String())
 }
 
 func (t *tokeniser) tokenise_operator() {
 	r := t.read()
 	switch r {
 	case '*':
-		t.tokens = append(t.tokens, Token{T_MULT, string(r)})
+		t.emit(T_MULT, string(r))
 	case '+':
-		t.tokens = append(t.tokens, Token{T_ADD, string(r)})
+		t.emit(T_ADD, string(r))
 	case '-':
-		t.tokens = append(t.tokens, Token{T_MINUS, string(r)})
+		t.emit(T_MINUS, string(r))
 	case '/':
-		t.tokens = append(t.tokens, Token{T_DIV, string(r)})
+		t.emit(T_DIV, string(r))
 	case '=':
 		if t.peek() == '=' {
 			t.read()
-			t.tokens = append(t.tokens, Token{T_EQ_EQ, "=="})
+			t.emit(T_EQ_EQ, "==")
 		} else {
-			t.tokens = append(t.tokens, Token{T_EQ, string(r)})
+			t.emit(T_EQ, string(r))
 		}
 	}
 }
 
+// peek gives the next byte without reading it, or 0 at the end of the
+// source.
 func (t *tokeniser) peek() byte {
+	if t.current >= len(t.src) {
+		return 0
+	}
 	return t.src[t.current]
 }
 
 func (t *tokeniser) tokenise_number() {
 	var sb strings.Builder
+	tt := T_INT
 
 	for t.current < len(t.src) {
 		r := t.peek()
 
 		if r >= '0' && r <= '9' {
 			sb.WriteByte(t.read())
+		} else if r == '.' && tt == T_INT && t.is_digit_at(t.current+1) {
+			tt = T_FLOAT
+			sb.WriteByte(t.read())
 		} else {
 			break
 		}
 	}
 
-	t.tokens = append(t.tokens, Token{T_INT, sb.String()})
+	t.emit(tt, sb.String())
+}
+
+func (t *tokeniser) is_digit_at(i int) bool {
+	return i < len(t.src) && t.src[i] >= '0' && t.src[i] <= '9'
 }
 
 func (t *tokeniser) read() byte {
 	r := t.src[t.current]
 	t.current++
+	if r == '\n' {
+		t.line++
+		t.col = 1
+	} else {
+		t.col++
+	}
 	return r
 }
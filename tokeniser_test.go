@@ -1,9 +1,12 @@
 package laks
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestTokenise(t *testing.T) {
@@ -18,92 +21,121 @@ func TestTokenise(t *testing.T) {
 		{
 			in: "4",
 			want: []Token{
-				{T_INT, "4"},
+				{T: T_INT, Lexeme: "4"},
 			},
 		},
 		{
 			in: "4;",
 			want: []Token{
-				{T_INT, "4"},
-				{T_SEMI, ";"},
+				{T: T_INT, Lexeme: "4"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 		},
 		{
 			in: "8 * 7;",
 			want: []Token{
-				{T_INT, "8"},
-				{T_MULT, "*"},
-				{T_INT, "7"},
-				{T_SEMI, ";"},
+				{T: T_INT, Lexeme: "8"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 		},
 		{
 			in: "\n\n2+2\t\t;",
 			want: []Token{
-				{T_INT, "2"},
-				{T_ADD, "+"},
-				{T_INT, "2"},
-				{T_SEMI, ";"},
+				{T: T_INT, Lexeme: "2"},
+				{T: T_ADD, Lexeme: "+"},
+				{T: T_INT, Lexeme: "2"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 		},
 		{
 			in: "+/-*",
 			want: []Token{
-				{T_ADD, "+"},
-				{T_DIV, "/"},
-				{T_MINUS, "-"},
-				{T_MULT, "*"},
+				{T: T_ADD, Lexeme: "+"},
+				{T: T_DIV, Lexeme: "/"},
+				{T: T_MINUS, Lexeme: "-"},
+				{T: T_MULT, Lexeme: "*"},
 			},
 		},
 		{
 			in: "print 7*8;",
 			want: []Token{
-				{T_KEYWORD, "print"},
-				{T_INT, "7"},
-				{T_MULT, "*"},
-				{T_INT, "8"},
-				{T_SEMI, ";"},
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "8"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 		},
 		{
 			in: "print 7*8; # this is a comment",
 			want: []Token{
-				{T_KEYWORD, "print"},
-				{T_INT, "7"},
-				{T_MULT, "*"},
-				{T_INT, "8"},
-				{T_SEMI, ";"},
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "8"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 		},
 		{
 			in: "# this is a comment\nprint 7*8;",
 			want: []Token{
-				{T_KEYWORD, "print"},
-				{T_INT, "7"},
-				{T_MULT, "*"},
-				{T_INT, "8"},
-				{T_SEMI, ";"},
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_INT, Lexeme: "7"},
+				{T: T_MULT, Lexeme: "*"},
+				{T: T_INT, Lexeme: "8"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 		},
 		{
 			in: "==",
 			want: []Token{
-				{T_EQ_EQ, "=="},
+				{T: T_EQ_EQ, Lexeme: "=="},
 			},
 		},
 		{
 			in: "true == false",
 			want: []Token{
-				{T_KEYWORD, "true"},
-				{T_EQ_EQ, "=="},
-				{T_KEYWORD, "false"},
+				{T: T_KEYWORD, Lexeme: "true"},
+				{T: T_EQ_EQ, Lexeme: "=="},
+				{T: T_KEYWORD, Lexeme: "false"},
 			},
 		},
 		{
 			in: "print \"foobar!\"",
 			want: []Token{
-				{T_KEYWORD, "print"},
-				{T_STRING, "foobar!"},
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_STRING, Lexeme: "foobar!"},
+			},
+		},
+		{
+			in: "print starts_with(s, \"x\");",
+			want: []Token{
+				{T: T_KEYWORD, Lexeme: "print"},
+				{T: T_KEYWORD, Lexeme: "starts_with"},
+				{T: T_LPAREN, Lexeme: "("},
+				{T: T_KEYWORD, Lexeme: "s"},
+				{T: T_COMMA, Lexeme: ","},
+				{T: T_STRING, Lexeme: "x"},
+				{T: T_RPAREN, Lexeme: ")"},
+				{T: T_SEMI, Lexeme: ";"},
+			},
+		},
+		{
+			in: "math.pow(-1.5, 2.);",
+			want: []Token{
+				{T: T_KEYWORD, Lexeme: "math"},
+				{T: T_DOT, Lexeme: "."},
+				{T: T_KEYWORD, Lexeme: "pow"},
+				{T: T_LPAREN, Lexeme: "("},
+				{T: T_MINUS, Lexeme: "-"},
+				{T: T_FLOAT, Lexeme: "1.5"},
+				{T: T_COMMA, Lexeme: ","},
+				{T: T_INT, Lexeme: "2"},
+				{T: T_DOT, Lexeme: "."},
+				{T: T_RPAREN, Lexeme: ")"},
+				{T: T_SEMI, Lexeme: ";"},
 			},
 		},
 	}
@@ -114,9 +146,49 @@ func TestTokenise(t *testing.T) {
 			if err != nil {
 				tt.Fatalf("%s", err.Error())
 			}
-			if diff := cmp.Diff(tst.want, got); diff != "" {
+			if diff := cmp.Diff(tst.want, got, cmpopts.IgnoreTypes(Span{})); diff != "" {
 				tt.Errorf("Mismatch (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestTokeniseUnterminatedString(t *testing.T) {
+	_, err := Tokenise([]byte(`print "oops;`))
+	if !errors.Is(err, ErrUnterminatedString) {
+		t.Fatalf("wanted ErrUnterminatedString but got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "1:7: ") {
+		t.Errorf("wanted the position of the string but got %v", err)
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	got, err := TokeniseFile("a.lak", []byte("print 1;\n  x == \"hi\";"))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	want := []Span{
+		{Pos{"a.lak", 1, 1, 0}, Pos{"a.lak", 1, 6, 5}},
+		{Pos{"a.lak", 1, 7, 6}, Pos{"a.lak", 1, 8, 7}},
+		{Pos{"a.lak", 1, 8, 7}, Pos{"a.lak", 1, 9, 8}},
+		{Pos{"a.lak", 2, 3, 11}, Pos{"a.lak", 2, 4, 12}},
+		{Pos{"a.lak", 2, 5, 13}, Pos{"a.lak", 2, 7, 15}},
+		{Pos{"a.lak", 2, 8, 16}, Pos{"a.lak", 2, 12, 20}},
+		{Pos{"a.lak", 2, 12, 20}, Pos{"a.lak", 2, 13, 21}},
+	}
+	var spans []Span
+	for _, tok := range got {
+		spans = append(spans, tok.Span)
+	}
+	if diff := cmp.Diff(want, spans); diff != "" {
+		t.Errorf("Mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTokeniseErrorPosition(t *testing.T) {
+	_, err := TokeniseFile("a.lak", []byte("print 1;\nprint $;"))
+	if err == nil || err.Error() != "a.lak:2:7: cannot tokenise '$'" {
+		t.Errorf("wanted a positioned error but got %v", err)
+	}
+}
@@ -18,11 +18,18 @@ func _() {
 	_ = x[T_EQ-7]
 	_ = x[T_EQ_EQ-8]
 	_ = x[T_STRING-9]
+	_ = x[T_LPAREN-10]
+	_ = x[T_RPAREN-11]
+	_ = x[T_COMMA-12]
+	_ = x[T_FLOAT-13]
+	_ = x[T_DOT-14]
+	_ = x[T_LBRACE-15]
+	_ = x[T_RBRACE-16]
 }
 
-const _TokenType_name = "T_INTT_SEMIT_MULTT_ADDT_DIVT_MINUST_KEYWORDT_EQT_EQ_EQT_STRING"
+const _TokenType_name = "T_INTT_SEMIT_MULTT_ADDT_DIVT_MINUST_KEYWORDT_EQT_EQ_EQT_STRINGT_LPARENT_RPARENT_COMMAT_FLOATT_DOTT_LBRACET_RBRACE"
 
-var _TokenType_index = [...]uint8{0, 5, 11, 17, 22, 27, 34, 43, 47, 54, 62}
+var _TokenType_index = [...]uint8{0, 5, 11, 17, 22, 27, 34, 43, 47, 54, 62, 70, 78, 85, 92, 97, 105, 113}
 
 func (i TokenType) String() string {
 	if i >= TokenType(len(_TokenType_index)-1) {
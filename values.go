@@ -0,0 +1,102 @@
+package laks
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ToValue converts a Go value into the laks value scripts see. Every size
+// of int and uint becomes an int, float32 and float64 become floats, and
+// slices and arrays become lists of their converted elements. Structs,
+// pointers to structs and maps with string keys become a *HostObject. nil
+// becomes nil, and laks values are returned as they are.
+func ToValue(v any) (Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return NilValue{}, nil
+	case IntValue, FloatValue, StringValue, TrueValue, FalseValue, NilValue, ListValue, *NativeFunction, *ModuleValue, *HostObject:
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return bool_value(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntValue(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("cannot convert %d to an int. it is too big", rv.Uint())
+		}
+		return IntValue(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return FloatValue(rv.Float()), nil
+	case reflect.String:
+		return StringValue(rv.String()), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return ListValue{}, nil
+		}
+		list := make(ListValue, rv.Len())
+		for i := range list {
+			e, err := ToValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("error converting element %d. %w", i, err)
+			}
+			list[i] = e
+		}
+		return list, nil
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return NilValue{}, nil
+		}
+		if rv.Elem().Kind() == reflect.Struct {
+			return &HostObject{v: rv.Elem()}, nil
+		}
+	case reflect.Struct:
+		// A copy, so that setting fields works without changing v.
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		return &HostObject{v: p.Elem()}, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			return &HostObject{v: rv}, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %T to a laks value", v)
+}
+
+// FromValue converts a laks value into a plain Go value: an int64,
+// float64, string, bool, nil, or []any for a list. A *HostObject gives back
+// a pointer to its struct, or its map. Functions and modules are returned
+// as they are.
+func FromValue(v Value) any {
+	switch v := v.(type) {
+	case IntValue:
+		return int64(v)
+	case FloatValue:
+		return float64(v)
+	case StringValue:
+		return string(v)
+	case TrueValue:
+		return true
+	case FalseValue:
+		return false
+	case NilValue:
+		return nil
+	case ListValue:
+		list := make([]any, len(v))
+		for i, e := range v {
+			list[i] = FromValue(e)
+		}
+		return list
+	case *HostObject:
+		if v.v.Kind() == reflect.Map {
+			return v.v.Interface()
+		}
+		return v.v.Addr().Interface()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,83 @@
+package laks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToValue(t *testing.T) {
+	type celsius float32
+	var tests = []struct {
+		name string
+		in   any
+		want Value
+	}{
+		{"nil", nil, NilValue{}},
+		{"int", 3, IntValue(3)},
+		{"int8", int8(-3), IntValue(-3)},
+		{"uint64", uint64(7), IntValue(7)},
+		{"float", 1.5, FloatValue(1.5)},
+		{"named float", celsius(2.5), FloatValue(2.5)},
+		{"string", "hi", StringValue("hi")},
+		{"bool", true, TrueValue(true)},
+		{"false", false, FalseValue(false)},
+		{"slice", []int{1, 2}, ListValue{IntValue(1), IntValue(2)}},
+		{"nested", []any{"a", []bool{false}}, ListValue{StringValue("a"), ListValue{FalseValue(false)}}},
+		{"nil slice", []string(nil), ListValue{}},
+		{"array", [2]string{"a", "b"}, ListValue{StringValue("a"), StringValue("b")}},
+		{"value", StringValue("kept"), StringValue("kept")},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(tt *testing.T) {
+			got, err := ToValue(tst.in)
+			if err != nil {
+				tt.Fatalf("%s", err.Error())
+			}
+			if diff := cmp.Diff(tst.want, got); diff != "" {
+				tt.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestToValueErrors(t *testing.T) {
+	var tests = []struct {
+		in   any
+		want string
+	}{
+		{complex(1, 2), "cannot convert complex128 to a laks value"},
+		{map[int]string{}, "cannot convert map[int]string to a laks value"},
+		{uint64(1 << 63), "cannot convert 9223372036854775808 to an int. it is too big"},
+		{[]any{1, make(chan int)}, "error converting element 1. cannot convert chan int to a laks value"},
+	}
+
+	for _, tst := range tests {
+		_, err := ToValue(tst.in)
+		if err == nil || err.Error() != tst.want {
+			t.Errorf("wanted error %q but got %v", tst.want, err)
+		}
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	var tests = []struct {
+		in   Value
+		want any
+	}{
+		{IntValue(3), int64(3)},
+		{FloatValue(1.5), 1.5},
+		{StringValue("hi"), "hi"},
+		{TrueValue(true), true},
+		{FalseValue(false), false},
+		{NilValue{}, nil},
+		{ListValue{IntValue(1), ListValue{StringValue("a")}}, []any{int64(1), []any{"a"}}},
+	}
+
+	for _, tst := range tests {
+		if diff := cmp.Diff(tst.want, FromValue(tst.in)); diff != "" {
+			t.Errorf("%v: Mismatch (-want +got):\n%s", tst.in, diff)
+		}
+	}
+}
@@ -12,11 +12,17 @@ func _() {
 	_ = x[VAL_TRUE-1]
 	_ = x[VAL_FALSE-2]
 	_ = x[VAL_STRING-3]
+	_ = x[VAL_FLOAT-4]
+	_ = x[VAL_NIL-5]
+	_ = x[VAL_LIST-6]
+	_ = x[VAL_FUNCTION-7]
+	_ = x[VAL_MODULE-8]
+	_ = x[VAL_OBJECT-9]
 }
 
-const _ValueType_name = "VAL_INTVAL_TRUEVAL_FALSEVAL_STRING"
+const _ValueType_name = "VAL_INTVAL_TRUEVAL_FALSEVAL_STRINGVAL_FLOATVAL_NILVAL_LISTVAL_FUNCTIONVAL_MODULEVAL_OBJECT"
 
-var _ValueType_index = [...]uint8{0, 7, 15, 24, 34}
+var _ValueType_index = [...]uint8{0, 7, 15, 24, 34, 43, 50, 58, 70, 80, 90}
 
 func (i ValueType) String() string {
 	if i >= ValueType(len(_ValueType_index)-1) {